@@ -0,0 +1,131 @@
+package search
+
+import (
+	"sync"
+	"time"
+)
+
+type CircuitState string
+
+const (
+	CircuitClosed   CircuitState = "closed"
+	CircuitOpen     CircuitState = "open"
+	CircuitHalfOpen CircuitState = "half-open"
+)
+
+type circuitBreaker struct {
+	threshold int
+	cooldown  time.Duration
+	state     CircuitState
+	failures  int
+	openedAt  time.Time
+	probing   bool
+	mux       sync.Mutex
+}
+
+func newCircuitBreaker(threshold int, cooldown time.Duration) *circuitBreaker {
+	return &circuitBreaker{
+		threshold: threshold,
+		cooldown:  cooldown,
+		state:     CircuitClosed,
+	}
+}
+
+// allow tells if a request can be sent to the backend, letting a single probe through after the cooldown
+func (breaker *circuitBreaker) allow() bool {
+	breaker.mux.Lock()
+	defer breaker.mux.Unlock()
+
+	switch breaker.state {
+	case CircuitOpen:
+		if time.Since(breaker.openedAt) < breaker.cooldown {
+			return false
+		}
+		breaker.state = CircuitHalfOpen
+		breaker.probing = true
+		return true
+	case CircuitHalfOpen:
+		if breaker.probing {
+			return false
+		}
+		breaker.probing = true
+		return true
+	}
+
+	return true
+}
+
+func (breaker *circuitBreaker) success() {
+	breaker.mux.Lock()
+	defer breaker.mux.Unlock()
+
+	breaker.state = CircuitClosed
+	breaker.failures = 0
+	breaker.probing = false
+}
+
+func (breaker *circuitBreaker) failure() {
+	breaker.mux.Lock()
+	defer breaker.mux.Unlock()
+
+	breaker.failures++
+	breaker.probing = false
+
+	if breaker.state == CircuitHalfOpen || breaker.failures >= breaker.threshold {
+		breaker.state = CircuitOpen
+		breaker.openedAt = time.Now()
+	}
+}
+
+func (breaker *circuitBreaker) release() {
+	breaker.mux.Lock()
+	defer breaker.mux.Unlock()
+
+	breaker.probing = false
+}
+
+func (breaker *circuitBreaker) State() CircuitState {
+	breaker.mux.Lock()
+	defer breaker.mux.Unlock()
+
+	if breaker.state == CircuitOpen && time.Since(breaker.openedAt) >= breaker.cooldown {
+		return CircuitHalfOpen
+	}
+
+	return breaker.state
+}
+
+// circuitBreaker returns the circuit breaker with the name, by default the name of the backend, when enabled
+func (search *Search) circuitBreaker(name string) *circuitBreaker {
+	if search.breakerThreshold <= 0 {
+		return nil
+	}
+
+	search.mux.Lock()
+	defer search.mux.Unlock()
+
+	if search.breakers == nil {
+		search.breakers = make(map[string]*circuitBreaker)
+	}
+
+	breaker, ok := search.breakers[name]
+	if !ok {
+		breaker = newCircuitBreaker(search.breakerThreshold, search.breakerCooldown)
+		search.breakers[name] = breaker
+	}
+
+	return breaker
+}
+
+// CircuitStates returns the state of each circuit breaker by its name, for health checks
+func (search *Search) CircuitStates() map[string]CircuitState {
+	search.mux.Lock()
+	defer search.mux.Unlock()
+
+	states := make(map[string]CircuitState)
+	for name, breaker := range search.breakers {
+		states[name] = breaker.State()
+	}
+
+	return states
+}
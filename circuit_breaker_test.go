@@ -0,0 +1,104 @@
+package search
+
+import (
+	"context"
+	goerrors "errors"
+	"testing"
+	"time"
+)
+
+// testClient answers the searches with the total and error, counting the executions
+type testClient struct {
+	name  string
+	total int
+	err   error
+	calls int
+}
+
+func (client *testClient) Exec(ctx context.Context, searchData *searchData) (int, error) {
+	client.calls++
+	return client.total, client.err
+}
+
+func (client *testClient) backend() string {
+	return client.name
+}
+
+func TestCircuitBreaker(t *testing.T) {
+	tests := []struct {
+		name     string
+		cooldown time.Duration
+		events   []string
+		allowed  bool
+		state    CircuitState
+	}{
+		{name: "closed", cooldown: time.Hour, events: nil, allowed: true, state: CircuitClosed},
+		{name: "failures below the threshold", cooldown: time.Hour, events: []string{"failure"}, allowed: true, state: CircuitClosed},
+		{name: "failures reaching the threshold", cooldown: time.Hour, events: []string{"failure", "failure"}, allowed: false, state: CircuitOpen},
+		{name: "success resets the failures", cooldown: time.Hour, events: []string{"failure", "success", "failure"}, allowed: true, state: CircuitClosed},
+		{name: "release keeps the failures", cooldown: time.Hour, events: []string{"failure", "release", "failure"}, allowed: false, state: CircuitOpen},
+		{name: "half open after the cooldown", cooldown: 0, events: []string{"failure", "failure"}, allowed: true, state: CircuitHalfOpen},
+		{name: "single probe when half open", cooldown: 0, events: []string{"failure", "failure", "allow"}, allowed: false, state: CircuitHalfOpen},
+		{name: "probe succeeding", cooldown: 0, events: []string{"failure", "failure", "allow", "success"}, allowed: true, state: CircuitClosed},
+		{name: "probe failing", cooldown: time.Hour, events: []string{"failure", "failure", "open", "allow", "failure"}, allowed: false, state: CircuitOpen},
+		{name: "probe released", cooldown: 0, events: []string{"failure", "failure", "allow", "release"}, allowed: true, state: CircuitHalfOpen},
+	}
+
+	for _, test := range tests {
+		breaker := newCircuitBreaker(2, test.cooldown)
+		for _, event := range test.events {
+			switch event {
+			case "allow":
+				breaker.allow()
+			case "success":
+				breaker.success()
+			case "failure":
+				breaker.failure()
+			case "release":
+				breaker.release()
+			case "open":
+				// the cooldown elapsed
+				breaker.openedAt = time.Now().Add(-2 * test.cooldown)
+			}
+		}
+
+		if state := breaker.State(); state != test.state {
+			t.Errorf("%s: state %s, expected %s", test.name, state, test.state)
+		}
+
+		if allowed := breaker.allow(); allowed != test.allowed {
+			t.Errorf("%s: allowed %t, expected %t", test.name, allowed, test.allowed)
+		}
+	}
+}
+
+// TestExecClientCircuitBreaker checks which errors of a probe close, open or keep the circuit half open
+func TestExecClientCircuitBreaker(t *testing.T) {
+	tests := []struct {
+		err   error
+		state CircuitState
+	}{
+		{err: nil, state: CircuitClosed},
+		{err: newBackendUnavailableError(constBackendDatabase, goerrors.New("connection refused")), state: CircuitOpen},
+		{err: context.DeadlineExceeded, state: CircuitOpen},
+		{err: context.Canceled, state: CircuitHalfOpen},
+		{err: ErrorCursorWithoutOrder, state: CircuitHalfOpen},
+		{err: newInvalidCursorError("cursor"), state: CircuitHalfOpen},
+		{err: goerrors.New("syntax error"), state: CircuitHalfOpen},
+	}
+
+	for _, test := range tests {
+		breaker := newCircuitBreaker(1, time.Hour)
+		breaker.failure()
+		breaker.openedAt = time.Now().Add(-2 * time.Hour)
+
+		searchHandler := &searchHandler{client: &testClient{name: constBackendDatabase, err: test.err}, breaker: breaker}
+		if _, err := searchHandler.execClient(context.Background(), &searchData{}); !goerrors.Is(err, test.err) {
+			t.Errorf("error %v: returned %v", test.err, err)
+		}
+
+		if state := breaker.State(); state != test.state {
+			t.Errorf("error %v: state %s, expected %s", test.err, state, test.state)
+		}
+	}
+}
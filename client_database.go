@@ -1,86 +1,145 @@
 package search
 
 import (
+	"context"
 	"fmt"
 	"reflect"
+	"strings"
 
 	"github.com/joaosoft/dbr"
 )
 
 type databaseClient struct {
 	*dbr.StmtSelect
+	collation string
 }
 
 func (search *Search) newDatabaseClient(stmt *dbr.StmtSelect) *databaseClient {
-	return &databaseClient{StmtSelect: stmt}
+	return &databaseClient{StmtSelect: stmt, collation: search.collation}
 }
 
-func (client *databaseClient) Exec(searchData *searchData) (int, error) {
+func (client *databaseClient) backend() string {
+	return constBackendDatabase
+}
+
+func (client *databaseClient) Exec(ctx context.Context, searchData *searchData) (int, error) {
 	var err error
 
+	orders := searchData.orders
+	if searchData.paginationMode == paginationModeCursor && searchData.cursor != nil && searchData.cursor.Backward {
+		orders = orders.reverse()
+	}
+
+	if err = validateIdentifiers(searchData, orders); err != nil {
+		return 0, err
+	}
+
 	// query
-	for key, value := range searchData.query {
-		client.Where(fmt.Sprintf("%s = ?", key), value)
+	for _, condition := range searchData.query {
+		client.where(condition)
 	}
 
 	// search
-	lenQ := len(searchData.searchFilters)
-	if searchData.search != nil && lenQ > 0 {
-		queryFilter := ""
-		for i, filter := range searchData.searchFilters {
-			queryFilter += fmt.Sprintf("%s ILIKE %s", filter, client.Db.Dialect.Encode("%"+*searchData.search+"%"))
-
-			if i < lenQ-1 {
-				queryFilter += " OR "
-			}
-		}
+	if searchData.fullText != nil && client.Db.Dialect.Name() != constDialectPostgres {
+		return 0, ErrorFullTextDialect
+	}
 
-		client.Where(fmt.Sprintf("(%s)", queryFilter))
+	if query := client.searchPredicate(searchData); query != "" {
+		client.Where(query)
 	}
 
 	// pagination
 	total := 0
-	if searchData.hasPagination {
-		_, err = client.Dbr.Select("count(1)").From(dbr.As(client.StmtSelect, "search")).Load(&total)
+	switch searchData.paginationMode {
+	case paginationModeCursor:
+		if len(orders) == 0 {
+			return 0, ErrorCursorWithoutOrder
+		}
 
-		if err != nil {
-			return 0, err
+		// the rank isn't loaded with the rows to build the cursors
+		if orders.contains(constRelevanceColumn) {
+			return 0, newUnsupportedSortError(constRelevance)
 		}
 
-		if total == 0 {
-			return 0, nil
+		if searchData.cursor != nil {
+			if len(searchData.cursor.Values) != len(orders) {
+				return 0, newInvalidCursorError(searchData.cursor.encode())
+			}
+
+			client.Where(client.seekPredicate(orders, searchData.cursor.Values))
 		}
-	}
 
-	if searchData.size > 0 {
-		client.Limit(searchData.size)
-	}
+		if searchData.size > 0 {
+			client.Limit(searchData.size + 1)
+		}
+	default:
+		if searchData.hasPagination {
+			err = run(ctx, func() error {
+				_, err := client.Dbr.Select("count(1)").From(dbr.As(client.StmtSelect, "search")).Load(&total)
+				return err
+			})
+
+			if err != nil {
+				return 0, err
+			}
 
-	if searchData.page > 0 {
-		client.Offset((searchData.page - 1) * searchData.size)
+			searchData.hasTotal = true
+			searchData.isTotalExact = true
+
+			if total == 0 {
+				return 0, nil
+			}
+		}
+
+		if searchData.size > 0 {
+			client.Limit(searchData.size)
+		}
+
+		if searchData.page > 0 {
+			client.Offset((searchData.page - 1) * searchData.size)
+		}
 	}
 
 	// order by
-	for _, order := range searchData.orders {
+	for _, order := range orders {
+		column := client.column(order.column)
+		if order.column == constRelevanceColumn {
+			if column = client.rank(searchData); column == "" {
+				continue
+			}
+		}
+
 		switch order.direction {
 		case orderAsc:
-			client.OrderAsc(order.column)
+			client.OrderAsc(column)
 		case orderDesc:
-			client.OrderDesc(order.column)
+			client.OrderDesc(column)
 		}
 	}
 
-	_, err = client.Load(searchData.object)
+	err = run(ctx, func() error {
+		_, err := client.Load(searchData.object)
+		return err
+	})
 	if err != nil {
 		return 0, err
 	}
 
+	if searchData.paginationMode == paginationModeCursor {
+		searchData.setCursors(func(_ int, row reflect.Value) ([]interface{}, bool) {
+			return rowValues(row, searchData.orders, constTagDatabase)
+		})
+	}
+
 	// Metadata
 	if searchData.hasMetadata {
 		for _, item := range searchData.metadata {
 			// function
 			if item.function != nil {
-				if err = item.function(reflect.ValueOf(searchData.object).Elem().Interface(), item.object, searchData.metadata); err != nil {
+				err = run(ctx, func() error {
+					return item.function(reflect.ValueOf(searchData.object).Elem().Interface(), item.object, searchData.metadata)
+				})
+				if err != nil {
 					return 0, err
 				}
 			}
@@ -88,7 +147,13 @@ func (client *databaseClient) Exec(searchData *searchData) (int, error) {
 			// statement
 			if item.stmt != nil {
 				if stmt, ok := item.stmt.(*dbr.StmtSelect); ok {
-					stmt.Load(item.object)
+					err = run(ctx, func() error {
+						_, err := stmt.Load(item.object)
+						return err
+					})
+					if err != nil {
+						return 0, err
+					}
 				}
 			}
 		}
@@ -96,3 +161,132 @@ func (client *databaseClient) Exec(searchData *searchData) (int, error) {
 
 	return total, err
 }
+
+func (client *databaseClient) where(condition *condition) {
+	if query := client.predicate(condition); query != "" {
+		client.Where(query)
+	}
+}
+
+// predicate builds the condition with the values encoded by the dialect instead of placeholders,
+// so the values can't be mixed by the placeholder replacement of dbr
+func (client *databaseClient) predicate(condition *condition) string {
+	column := client.column(condition.column)
+	values := condition.interfaces()
+
+	switch condition.operator {
+	case operatorEqual:
+		return fmt.Sprintf("%s = %s", column, client.literal(values[0]))
+	case operatorNotEqual:
+		return fmt.Sprintf("%s <> %s", column, client.literal(values[0]))
+	case operatorGreater:
+		return fmt.Sprintf("%s > %s", column, client.literal(values[0]))
+	case operatorGreaterOrEqual:
+		return fmt.Sprintf("%s >= %s", column, client.literal(values[0]))
+	case operatorLess:
+		return fmt.Sprintf("%s < %s", column, client.literal(values[0]))
+	case operatorLessOrEqual:
+		return fmt.Sprintf("%s <= %s", column, client.literal(values[0]))
+	case operatorIn:
+		return fmt.Sprintf("%s IN (%s)", column, client.literals(values))
+	case operatorBetween:
+		return fmt.Sprintf("%s BETWEEN %s AND %s", column, client.literal(values[0]), client.literal(values[1]))
+	case operatorLike:
+		return client.like(column, condition.values[0])
+	case operatorIsNull:
+		if condition.isNull() {
+			return fmt.Sprintf("%s IS NULL", column)
+		}
+		return fmt.Sprintf("%s IS NOT NULL", column)
+	case operatorOr:
+		queries := make([]string, 0, len(condition.children))
+		for _, child := range condition.children {
+			queries = append(queries, client.predicate(child))
+		}
+		return fmt.Sprintf("(%s)", strings.Join(queries, " OR "))
+	}
+
+	return ""
+}
+
+// seekPredicate builds the keyset condition that selects the rows after the cursor values,
+// as a row comparison "(col1, col2) > (val1, val2)" when all the orders share the same direction
+// and the cursor has no null values, that are compared by the null ordering of the dialect
+func (client *databaseClient) seekPredicate(orders orders, values []interface{}) string {
+	rowComparison := true
+	for i, order := range orders {
+		rowComparison = rowComparison && order.direction == orders[0].direction && values[i] != nil
+	}
+
+	if rowComparison {
+		columns := make([]string, len(orders))
+		for i, order := range orders {
+			columns[i] = client.column(order.column)
+		}
+
+		return fmt.Sprintf("(%s) %s (%s)", strings.Join(columns, ", "), comparator(orders[0].direction), client.literals(values))
+	}
+
+	// (col1 > val1) OR (col1 = val1 AND col2 < val2) ...
+	queries := make([]string, 0, len(orders))
+	for i, order := range orders {
+		after := client.afterPredicate(order, values[i])
+		if after == "" {
+			continue
+		}
+
+		parts := make([]string, 0, i+1)
+		for j := 0; j < i; j++ {
+			parts = append(parts, client.equalPredicate(orders[j], values[j]))
+		}
+		parts = append(parts, after)
+
+		queries = append(queries, fmt.Sprintf("(%s)", strings.Join(parts, " AND ")))
+	}
+
+	if len(queries) == 0 {
+		return "1 = 0"
+	}
+
+	return fmt.Sprintf("(%s)", strings.Join(queries, " OR "))
+}
+
+func comparator(direction direction) string {
+	if direction == orderDesc {
+		return "<"
+	}
+	return ">"
+}
+
+// equalPredicate selects the rows with the cursor value on the order column
+func (client *databaseClient) equalPredicate(order *order, value interface{}) string {
+	if value == nil {
+		return fmt.Sprintf("%s IS NULL", client.column(order.column))
+	}
+
+	return fmt.Sprintf("%s = %s", client.column(order.column), client.literal(value))
+}
+
+// afterPredicate selects the rows after the cursor value on the order column, with the nulls after or
+// before the other values as sorted by the dialect, or is empty when there are no rows after it
+func (client *databaseClient) afterPredicate(order *order, value interface{}) string {
+	column := client.column(order.column)
+	nullsLast := client.nullsLast(order.direction)
+
+	switch {
+	case value == nil && nullsLast:
+		return ""
+	case value == nil:
+		return fmt.Sprintf("%s IS NOT NULL", column)
+	case nullsLast:
+		return fmt.Sprintf("(%s %s %s OR %s IS NULL)", column, comparator(order.direction), client.literal(value), column)
+	}
+
+	return fmt.Sprintf("%s %s %s", column, comparator(order.direction), client.literal(value))
+}
+
+// nullsLast tells if the nulls are sorted after the other values on the direction, postgres sorts
+// the nulls as the largest values while mysql and sqlite sort them as the smallest ones
+func (client *databaseClient) nullsLast(direction direction) bool {
+	return (client.Db.Dialect.Name() == constDialectPostgres) == (direction != orderDesc)
+}
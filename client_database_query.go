@@ -0,0 +1,327 @@
+package search
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+const (
+	constDialectPostgres = "postgres"
+	constDialectMysql    = "mysql"
+	constDialectSqlite   = "sqlite3"
+
+	constLikeEscape = `\`
+
+	// constPlaceholder is the placeholder of all the dbr dialects
+	constPlaceholder = "?"
+)
+
+// fullText is the postgres full text search over the search fields or a tsvector column
+type fullText struct {
+	config string
+	column string
+}
+
+func newFullText(config string, vectorColumn ...string) *fullText {
+	fullText := &fullText{config: config}
+	if len(vectorColumn) > 0 {
+		fullText.column = vectorColumn[0]
+	}
+	return fullText
+}
+
+// identifierRegex accepts a column optionally qualified by its table and schema
+var identifierRegex = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*(\.[A-Za-z_][A-Za-z0-9_]*){0,2}$`)
+
+// validateIdentifiers checks the columns of the conditions, search filters and orders,
+// before any of them is added to the statement
+func validateIdentifiers(searchData *searchData, orders orders) error {
+	columns := make([]string, 0)
+
+	var conditionColumns func(conditions conditions)
+	conditionColumns = func(conditions conditions) {
+		for _, condition := range conditions {
+			columns = append(columns, condition.column)
+			conditionColumns(condition.children)
+		}
+	}
+	conditionColumns(searchData.query)
+
+	columns = append(columns, searchData.searchFilters...)
+	for _, order := range orders {
+		if order.column != constRelevanceColumn {
+			columns = append(columns, order.column)
+		}
+	}
+
+	if searchData.fullText != nil && searchData.fullText.column != "" {
+		columns = append(columns, searchData.fullText.column)
+	}
+
+	for _, column := range columns {
+		if !identifierRegex.MatchString(column) {
+			return newInvalidIdentifierError(column)
+		}
+	}
+
+	return nil
+}
+
+// column quotes each part of a validated identifier with the dialect, mysql quotes with backticks
+// since dbr quotes with double quotes, that mysql only accepts in the ANSI_QUOTES mode
+func (client *databaseClient) column(name string) string {
+	parts := strings.Split(name, ".")
+	for i, part := range parts {
+		if client.Db.Dialect.Name() == constDialectMysql {
+			parts[i] = "`" + part + "`"
+		} else {
+			parts[i] = client.Db.Dialect.EncodeColumn(part)
+		}
+	}
+
+	return strings.Join(parts, ".")
+}
+
+// literal encodes a value with the dialect, writing the placeholder character of the strings as a
+// function call, since dbr replaces the placeholders of a condition one by one on the built query
+// and a placeholder inside a value would receive the next value
+func (client *databaseClient) literal(value interface{}) string {
+	text, ok := value.(string)
+	if !ok || !strings.Contains(text, constPlaceholder) {
+		return client.Db.Dialect.Encode(value)
+	}
+
+	parts := strings.Split(text, constPlaceholder)
+	encoded := make([]string, len(parts))
+	for i, part := range parts {
+		encoded[i] = client.Db.Dialect.EncodeString(part)
+	}
+
+	switch client.Db.Dialect.Name() {
+	case constDialectMysql:
+		return fmt.Sprintf("CONCAT(%s)", strings.Join(encoded, ", CHAR(63 USING utf8mb4), "))
+	case constDialectSqlite:
+		return fmt.Sprintf("(%s)", strings.Join(encoded, " || CHAR(63) || "))
+	}
+
+	return fmt.Sprintf("(%s)", strings.Join(encoded, " || CHR(63) || "))
+}
+
+// likeEscape escapes the wildcards of the term
+func likeEscape(term string) string {
+	return strings.NewReplacer(constLikeEscape, constLikeEscape+constLikeEscape, "%", constLikeEscape+"%", "_", constLikeEscape+"_").Replace(term)
+}
+
+// likePattern returns the pattern matching the term with the match strategy
+func likePattern(term string, match match) string {
+	switch match {
+	case matchExact:
+		return likeEscape(term)
+	case matchPrefix:
+		return likeEscape(term) + "%"
+	}
+
+	return "%" + likeEscape(term) + "%"
+}
+
+// like builds the condition of a column containing the term
+func (client *databaseClient) like(column string, term string) string {
+	return fmt.Sprintf("%s LIKE %s ESCAPE %s", column, client.literal(likePattern(term, matchContains)), client.Db.Dialect.EncodeString(constLikeEscape))
+}
+
+// likeInsensitive builds the case insensitive condition of a column matching the term with the match strategy,
+// with ILIKE on postgres, the case insensitive collation of WithCollation on mysql and LOWER() on the others
+func (client *databaseClient) likeInsensitive(column string, term string, match match) string {
+	pattern := client.literal(likePattern(term, match))
+	escape := client.Db.Dialect.EncodeString(constLikeEscape)
+
+	switch client.Db.Dialect.Name() {
+	case constDialectPostgres:
+		return fmt.Sprintf("%s ILIKE %s ESCAPE %s", column, pattern, escape)
+	case constDialectMysql:
+		if client.collation != "" {
+			return fmt.Sprintf("%s COLLATE %s LIKE %s ESCAPE %s", column, client.collation, pattern, escape)
+		}
+	}
+
+	return fmt.Sprintf("LOWER(%s) LIKE LOWER(%s) ESCAPE %s", column, pattern, escape)
+}
+
+func (client *databaseClient) literals(values []interface{}) string {
+	encoded := make([]string, len(values))
+	for i, value := range values {
+		encoded[i] = client.literal(value)
+	}
+
+	return strings.Join(encoded, ", ")
+}
+
+// searchPredicate translates the parsed search, matching each term on its scoped field or on all the
+// search fields, with the full text search or a case insensitive like
+func (client *databaseClient) searchPredicate(searchData *searchData) string {
+	if searchData.fullText == nil && len(searchData.searchFilters) == 0 {
+		return ""
+	}
+
+	groups := make([]string, 0, len(searchData.searchQuery))
+	for _, group := range searchData.searchQuery {
+		terms := make([]string, 0, len(group))
+		for _, term := range group {
+			var predicate string
+			if searchData.fullText != nil {
+				predicate = client.termFullText(searchData, term)
+			} else {
+				predicate = client.termLike(searchData, term)
+			}
+
+			if predicate == "" {
+				continue
+			}
+
+			if term.excluded {
+				// the columns may be null, that must not exclude the row
+				predicate = fmt.Sprintf("NOT COALESCE(%s, FALSE)", predicate)
+			}
+			terms = append(terms, predicate)
+		}
+
+		if len(terms) > 0 {
+			groups = append(groups, fmt.Sprintf("(%s)", strings.Join(terms, " AND ")))
+		}
+	}
+
+	if len(groups) == 0 {
+		return ""
+	}
+
+	return fmt.Sprintf("(%s)", strings.Join(groups, " OR "))
+}
+
+func (client *databaseClient) termLike(searchData *searchData, term *searchTerm) string {
+	fields := term.fields(searchData.searchFilters)
+
+	matches := make([]string, len(fields))
+	for i, field := range fields {
+		matches[i] = client.termMatch(searchData, term, field)
+	}
+
+	return fmt.Sprintf("(%s)", strings.Join(matches, " OR "))
+}
+
+// termMatch matches the term on a search field with its match strategy, the fuzzy fields match the values containing
+// the term or, on postgres, with a trigram similarity above the threshold; the % operator lets the trigram indexes
+// serve the query but filters by the pg_trgm.similarity_threshold setting (0.3 by default), so it's only used for the
+// thresholds above it, the lower ones are only filtered by the similarity function without the indexes
+func (client *databaseClient) termMatch(searchData *searchData, term *searchTerm, field string) string {
+	column := client.column(field)
+	match := searchData.matchOf(field)
+
+	if match == matchFuzzy && client.Db.Dialect.Name() == constDialectPostgres {
+		value := client.literal(term.value)
+		threshold := searchData.similarity()
+
+		similar := fmt.Sprintf("similarity(%s, %s) >= %s", column, value, formatNumber(threshold))
+		if threshold >= constDefaultSimilarity {
+			similar = fmt.Sprintf("%s %% %s AND %s", column, value, similar)
+		}
+
+		return fmt.Sprintf("(%s OR (%s))", client.likeInsensitive(column, term.value, matchContains), similar)
+	}
+
+	return client.likeInsensitive(column, term.value, match)
+}
+
+func (client *databaseClient) termFullText(searchData *searchData, term *searchTerm) string {
+	vector := client.tsVector(searchData)
+	if term.field != "" {
+		vector = client.tsVectorOf(searchData, term.field)
+	} else if vector == "" {
+		return ""
+	}
+
+	function := "plainto_tsquery"
+	if term.phrase {
+		function = "phraseto_tsquery"
+	}
+
+	query := fmt.Sprintf("%s(%s::regconfig, %s)", function, client.literal(searchData.fullText.config), client.literal(term.value))
+
+	// a term with only stopwords (as "the" on "the beatles") is an empty query that matches nothing, it's ignored
+	if term.excluded {
+		return fmt.Sprintf("(numnode(%s) > 0 AND %s @@ %s)", query, vector, query)
+	}
+
+	return fmt.Sprintf("(numnode(%s) = 0 OR %s @@ %s)", query, vector, query)
+}
+
+// tsVector returns the tsvector column, or the tsvector of the search fields
+func (client *databaseClient) tsVector(searchData *searchData) string {
+	if searchData.fullText.column != "" {
+		return client.column(searchData.fullText.column)
+	}
+
+	if len(searchData.searchFilters) == 0 {
+		return ""
+	}
+
+	return client.tsVectorOf(searchData, searchData.searchFilters...)
+}
+
+func (client *databaseClient) tsVectorOf(searchData *searchData, fields ...string) string {
+	columns := make([]string, len(fields))
+	for i, field := range fields {
+		columns[i] = fmt.Sprintf("COALESCE(%s, '')", client.column(field))
+	}
+
+	return fmt.Sprintf("to_tsvector(%s::regconfig, %s)", client.literal(searchData.fullText.config), strings.Join(columns, " || ' ' || "))
+}
+
+// rank returns the expression of the relevance of the terms that aren't excluded,
+// ordering by nothing when there isn't a search term
+func (client *databaseClient) rank(searchData *searchData) string {
+	if searchData.fullText == nil {
+		return client.score(searchData)
+	}
+
+	vector := client.tsVector(searchData)
+	terms := searchData.searchQuery.included()
+	if vector == "" || len(terms) == 0 {
+		return ""
+	}
+
+	// websearch_to_tsquery doesn't fail on any syntax, the phrases are quoted again
+	values := make([]string, len(terms))
+	for i, term := range terms {
+		values[i] = term.value
+		if term.phrase {
+			values[i] = fmt.Sprintf(`"%s"`, term.value)
+		}
+	}
+
+	return fmt.Sprintf("ts_rank(%s, websearch_to_tsquery(%s::regconfig, %s))", vector, client.literal(searchData.fullText.config), client.literal(strings.Join(values, " or ")))
+}
+
+// score returns the sum of the boosts of the search fields matching each term that isn't excluded,
+// the boosts of the fuzzy fields on postgres are weighted by the similarity of the values to the term
+func (client *databaseClient) score(searchData *searchData) string {
+	scores := make([]string, 0)
+	for _, term := range searchData.searchQuery.included() {
+		for _, field := range term.fields(searchData.searchFilters) {
+			boost := formatNumber(searchData.searchFields.field(field).boost)
+
+			if searchData.matchOf(field) == matchFuzzy && client.Db.Dialect.Name() == constDialectPostgres {
+				// the similarity of a null value is null, that would make the score null
+				scores = append(scores, fmt.Sprintf("%s * COALESCE(similarity(%s, %s), 0)", boost, client.column(field), client.literal(term.value)))
+			} else {
+				scores = append(scores, fmt.Sprintf("CASE WHEN %s THEN %s ELSE 0 END", client.termMatch(searchData, term, field), boost))
+			}
+		}
+	}
+
+	if len(scores) == 0 {
+		return ""
+	}
+
+	return fmt.Sprintf("(%s)", strings.Join(scores, " + "))
+}
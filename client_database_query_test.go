@@ -0,0 +1,459 @@
+package search
+
+import (
+	goerrors "errors"
+	"fmt"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+
+	_ "github.com/go-sql-driver/mysql"
+	"github.com/joaosoft/dbr"
+	"github.com/joaosoft/manager"
+	_ "github.com/mattn/go-sqlite3"
+)
+
+type item struct {
+	Value string `db:"value"`
+}
+
+func newSqlite(t *testing.T) *dbr.Dbr {
+	t.Helper()
+
+	db, err := dbr.New(dbr.WithConfiguration(&dbr.DbrConfig{
+		Db: &manager.DBConfig{Driver: constDialectSqlite, DataSource: filepath.Join(t.TempDir(), "search.db")},
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := db.Execute("CREATE TABLE item (value TEXT)").Exec(); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, value := range []string{"100%", "100 percent", "a_b", "axb", `c:\temp`, "c:/temp", "Joao", "JOAO ribeiro", "what?"} {
+		if _, err := db.Insert().Into("item").Columns("value").Record(&item{Value: value}).Exec(); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	return db
+}
+
+// newTestClient creates a database client on a select of the dialect, only the sqlite one is connected
+func newTestClient(t *testing.T, dialect string) *databaseClient {
+	t.Helper()
+
+	if dialect == constDialectSqlite {
+		return &databaseClient{StmtSelect: newSqlite(t).Select("value").From("item")}
+	}
+
+	db, err := dbr.New(dbr.WithConfiguration(&dbr.DbrConfig{
+		Db: &manager.DBConfig{Driver: dialect, DataSource: "user:password@/search"},
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return &databaseClient{StmtSelect: db.Select("*").From("person")}
+}
+
+func TestValidateIdentifiers(t *testing.T) {
+	tests := []struct {
+		identifier string
+		valid      bool
+	}{
+		{identifier: "first_name", valid: true},
+		{identifier: "person.first_name", valid: true},
+		{identifier: "search.person.first_name", valid: true},
+		{identifier: "_private1", valid: true},
+		{identifier: "a.b.c.d", valid: false},
+		{identifier: "", valid: false},
+		{identifier: "1st", valid: false},
+		{identifier: "person.", valid: false},
+		{identifier: ".first_name", valid: false},
+		{identifier: "first name", valid: false},
+		{identifier: `first_name"`, valid: false},
+		{identifier: `"first_name"`, valid: false},
+		{identifier: "`first_name`", valid: false},
+		{identifier: "first_name'", valid: false},
+		{identifier: "first_name;--", valid: false},
+		{identifier: "first_name; DROP TABLE person", valid: false},
+		{identifier: "LOWER(first_name)", valid: false},
+		{identifier: "age+1", valid: false},
+		{identifier: "first_name/**/", valid: false},
+		{identifier: "first_name?", valid: false},
+	}
+
+	for _, test := range tests {
+		searchDatas := map[string]*searchData{
+			"condition":     {query: conditions{{column: "id", operator: operatorOr, children: conditions{{column: test.identifier, operator: operatorEqual, values: []string{"1"}}}}}},
+			"search filter": {searchFilters: []string{test.identifier}},
+			"order":         {},
+		}
+
+		// the full text search without a column is on the search fields
+		if test.identifier != "" {
+			searchDatas["full text"] = &searchData{fullText: newFullText("english", test.identifier)}
+		}
+
+		for kind, searchData := range searchDatas {
+			orders := orders{{column: constRelevanceColumn, direction: orderDesc}}
+			if kind == "order" {
+				orders = append(orders, &order{column: test.identifier, direction: orderAsc})
+			}
+
+			err := validateIdentifiers(searchData, orders)
+			if test.valid && err != nil {
+				t.Errorf("%s %q: unexpected error %s", kind, test.identifier, err)
+			}
+
+			var invalidIdentifier *InvalidIdentifierError
+			if !test.valid && (!goerrors.As(err, &invalidIdentifier) || invalidIdentifier.Identifier != test.identifier) {
+				t.Errorf("%s %q: expected an invalid identifier error, got %v", kind, test.identifier, err)
+			}
+		}
+	}
+}
+
+func TestColumn(t *testing.T) {
+	tests := []struct {
+		dialect  string
+		column   string
+		expected string
+	}{
+		{dialect: constDialectPostgres, column: "first_name", expected: `"first_name"`},
+		{dialect: constDialectPostgres, column: "search.person.first_name", expected: `"search"."person"."first_name"`},
+		{dialect: constDialectMysql, column: "first_name", expected: "`first_name`"},
+		{dialect: constDialectMysql, column: "person.first_name", expected: "`person`.`first_name`"},
+		{dialect: constDialectSqlite, column: "first_name", expected: `"first_name"`},
+		{dialect: constDialectSqlite, column: "person.first_name", expected: `"person"."first_name"`},
+	}
+
+	for _, test := range tests {
+		if column := newTestClient(t, test.dialect).column(test.column); column != test.expected {
+			t.Errorf("%s column %q = %s, expected %s", test.dialect, test.column, column, test.expected)
+		}
+	}
+}
+
+func TestLiteral(t *testing.T) {
+	tests := []struct {
+		dialect  string
+		value    interface{}
+		expected string
+	}{
+		{dialect: constDialectPostgres, value: "O'Reilly", expected: `'O''Reilly'`},
+		{dialect: constDialectPostgres, value: "'; DROP TABLE person;--", expected: `'''; DROP TABLE person;--'`},
+		{dialect: constDialectPostgres, value: "a?b", expected: `('a' || CHR(63) || 'b')`},
+		{dialect: constDialectPostgres, value: "?", expected: `('' || CHR(63) || '')`},
+		{dialect: constDialectPostgres, value: `\'`, expected: `'\'''`},
+		{dialect: constDialectPostgres, value: 10, expected: `10`},
+		{dialect: constDialectPostgres, value: true, expected: `TRUE`},
+		{dialect: constDialectMysql, value: "O'Reilly", expected: `'O\'Reilly'`},
+		{dialect: constDialectMysql, value: "'; DROP TABLE person;--", expected: `'\'; DROP TABLE person;--'`},
+		{dialect: constDialectMysql, value: "a?b", expected: `CONCAT('a', CHAR(63 USING utf8mb4), 'b')`},
+		{dialect: constDialectMysql, value: `\`, expected: `'\\'`},
+		{dialect: constDialectMysql, value: `\'`, expected: `'\\\''`},
+		{dialect: constDialectMysql, value: `x\`, expected: `'x\\'`},
+		{dialect: constDialectMysql, value: true, expected: `1`},
+		{dialect: constDialectSqlite, value: "O'Reilly", expected: `'O''Reilly'`},
+		{dialect: constDialectSqlite, value: "a?b", expected: `('a' || CHAR(63) || 'b')`},
+		{dialect: constDialectSqlite, value: `\'`, expected: `'\'''`},
+	}
+
+	for _, test := range tests {
+		if literal := newTestClient(t, test.dialect).literal(test.value); literal != test.expected {
+			t.Errorf("%s literal %#v = %s, expected %s", test.dialect, test.value, literal, test.expected)
+		}
+	}
+}
+
+// TestLiteralSqlite reads the literals back from sqlite, they must be the same values
+func TestLiteralSqlite(t *testing.T) {
+	values := []string{"O'Reilly", "'; DROP TABLE item;--", "a?b", "?", "??'?", `\`, `\'`, `x\`, `"quoted"`, "--", "/*", ""}
+
+	client := newTestClient(t, constDialectSqlite)
+	for _, value := range values {
+		items := make([]*item, 0)
+		if _, err := client.Dbr.Select(fmt.Sprintf("%s AS value", client.literal(value))).From("item").Limit(1).Load(&items); err != nil {
+			t.Errorf("literal %q: %s", value, err)
+			continue
+		}
+
+		if len(items) != 1 || items[0].Value != value {
+			t.Errorf("literal %q was read as %v", value, items)
+		}
+	}
+
+	// the table wasn't dropped
+	if _, err := client.Dbr.Select("value").From("item").Load(&[]*item{}); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestLikePattern(t *testing.T) {
+	tests := []struct {
+		term    string
+		match   match
+		pattern string
+	}{
+		{term: "joao", match: matchContains, pattern: "%joao%"},
+		{term: "joao", match: matchPrefix, pattern: "joao%"},
+		{term: "joao", match: matchExact, pattern: "joao"},
+		{term: "joao", match: matchFuzzy, pattern: "%joao%"},
+		{term: "100%", match: matchContains, pattern: `%100\%%`},
+		{term: "a_b", match: matchExact, pattern: `a\_b`},
+		{term: `c:\temp`, match: matchPrefix, pattern: `c:\\temp%`},
+	}
+
+	for _, test := range tests {
+		if pattern := likePattern(test.term, test.match); pattern != test.pattern {
+			t.Errorf("likePattern(%q, %s) = %q, expected %q", test.term, test.match, pattern, test.pattern)
+		}
+	}
+}
+
+func TestLikeInsensitiveSqlite(t *testing.T) {
+	db := newSqlite(t)
+
+	tests := []struct {
+		term     string
+		match    match
+		expected []string
+	}{
+		{term: "100%", match: matchContains, expected: []string{"100%"}},
+		{term: "%", match: matchContains, expected: []string{"100%"}},
+		{term: "a_b", match: matchContains, expected: []string{"a_b"}},
+		{term: "_", match: matchContains, expected: []string{"a_b"}},
+		{term: `\`, match: matchContains, expected: []string{`c:\temp`}},
+		{term: `c:\t`, match: matchPrefix, expected: []string{`c:\temp`}},
+		{term: "joao", match: matchContains, expected: []string{"JOAO ribeiro", "Joao"}},
+		{term: "JO", match: matchPrefix, expected: []string{"JOAO ribeiro", "Joao"}},
+		{term: "oao", match: matchPrefix, expected: []string{}},
+		{term: "joao", match: matchExact, expected: []string{"Joao"}},
+		{term: "t?", match: matchContains, expected: []string{"what?"}},
+	}
+
+	for _, test := range tests {
+		client := &databaseClient{StmtSelect: db.Select("value").From("item")}
+		client.Where(client.likeInsensitive(client.column("value"), test.term, test.match))
+
+		items := make([]*item, 0)
+		if _, err := client.Load(&items); err != nil {
+			t.Errorf("term %q (%s): %s", test.term, test.match, err)
+			continue
+		}
+
+		values := make([]string, 0, len(items))
+		for _, item := range items {
+			values = append(values, item.Value)
+		}
+		sort.Strings(values)
+
+		if !reflect.DeepEqual(values, test.expected) {
+			t.Errorf("term %q (%s) matched %q, expected %q", test.term, test.match, values, test.expected)
+		}
+	}
+}
+
+func TestLikeInsensitiveMysqlCollation(t *testing.T) {
+	db, err := dbr.New(dbr.WithConfiguration(&dbr.DbrConfig{
+		Db: &manager.DBConfig{Driver: constDialectMysql, DataSource: "user:password@/search"},
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		collation string
+		expected  string
+	}{
+		{collation: "", expected: "LOWER(`value`) LIKE LOWER('%joao%') ESCAPE '\\\\'"},
+		{collation: "utf8mb4_general_ci", expected: "`value` COLLATE utf8mb4_general_ci LIKE '%joao%' ESCAPE '\\\\'"},
+	}
+
+	for _, test := range tests {
+		client := &databaseClient{StmtSelect: db.Select("value").From("item"), collation: test.collation}
+		if condition := client.likeInsensitive(client.column("value"), "joao", matchContains); condition != test.expected {
+			t.Errorf("collation %q: %s, expected %s", test.collation, condition, test.expected)
+		}
+	}
+}
+
+// TestSeekPredicateSqlite seeks the rows after hostile cursor values, that must be compared as plain values
+func TestSeekPredicateSqlite(t *testing.T) {
+	values := []string{"O'Reilly", "'; DROP TABLE item;--", "1) OR 1=1 --", "a?b", "?", `\'`, `c:\`, `"quoted"`, "/*", ""}
+	all := []string{"100%", "100 percent", "a_b", "axb", `c:\temp`, "c:/temp", "Joao", "JOAO ribeiro", "what?"}
+
+	db := newSqlite(t)
+	for _, value := range values {
+		cursor, err := decodeCursor((&cursor{Values: []interface{}{value}}).encode())
+		if err != nil {
+			t.Errorf("cursor %q: %s", value, err)
+			continue
+		}
+
+		client := &databaseClient{StmtSelect: db.Select("value").From("item")}
+		client.Where(client.seekPredicate(orders{{column: "value", direction: orderAsc}}, cursor.Values))
+
+		items := make([]*item, 0)
+		if _, err := client.Load(&items); err != nil {
+			t.Errorf("cursor %q: %s", value, err)
+			continue
+		}
+
+		found := make([]string, 0, len(items))
+		for _, item := range items {
+			found = append(found, item.Value)
+		}
+		sort.Strings(found)
+
+		expected := make([]string, 0)
+		for _, item := range all {
+			if item > value {
+				expected = append(expected, item)
+			}
+		}
+		sort.Strings(expected)
+
+		if !reflect.DeepEqual(found, expected) {
+			t.Errorf("cursor %q: rows %q, expected %q", value, found, expected)
+		}
+	}
+}
+
+func TestCollation(t *testing.T) {
+	tests := []struct {
+		collation string
+		valid     bool
+	}{
+		{collation: "utf8mb4_general_ci", valid: true},
+		{collation: "utf8mb4_0900_ai_ci", valid: true},
+		{collation: "utf8mb4_general_ci LIKE 'a' OR 1=1 --", valid: false},
+		{collation: "utf8mb4_general_ci;", valid: false},
+		{collation: "`utf8mb4_general_ci`", valid: false},
+	}
+
+	for _, test := range tests {
+		_, err := New(WithConfiguration(&SearchConfig{}), WithCollation(test.collation))
+		if test.valid && err != nil {
+			t.Errorf("collation %q: unexpected error %s", test.collation, err)
+		}
+
+		var invalidIdentifier *InvalidIdentifierError
+		if !test.valid && (!goerrors.As(err, &invalidIdentifier) || invalidIdentifier.Identifier != test.collation) {
+			t.Errorf("collation %q: expected an invalid identifier error, got %v", test.collation, err)
+		}
+	}
+}
+
+// TestTermFullText checks that the terms with only stopwords, that are empty queries, are ignored instead of matching nothing
+func TestTermFullText(t *testing.T) {
+	tests := []struct {
+		term     *searchTerm
+		expected string
+	}{
+		{term: &searchTerm{value: "the"}, expected: `(numnode(plainto_tsquery('english'::regconfig, 'the')) = 0 OR to_tsvector('english'::regconfig, COALESCE("name", '')) @@ plainto_tsquery('english'::regconfig, 'the'))`},
+		{term: &searchTerm{value: "the beatles", phrase: true}, expected: `(numnode(phraseto_tsquery('english'::regconfig, 'the beatles')) = 0 OR to_tsvector('english'::regconfig, COALESCE("name", '')) @@ phraseto_tsquery('english'::regconfig, 'the beatles'))`},
+		{term: &searchTerm{value: "the", excluded: true}, expected: `(numnode(plainto_tsquery('english'::regconfig, 'the')) > 0 AND to_tsvector('english'::regconfig, COALESCE("name", '')) @@ plainto_tsquery('english'::regconfig, 'the'))`},
+	}
+
+	client := newTestClient(t, constDialectPostgres)
+	for _, test := range tests {
+		searchData := &searchData{fullText: newFullText("english"), searchFilters: []string{"name"}}
+		if predicate := client.termFullText(searchData, test.term); predicate != test.expected {
+			t.Errorf("term %s: %s, expected %s", test.term.value, predicate, test.expected)
+		}
+	}
+}
+
+// TestTermMatchFuzzy checks that the % operator, filtering by the similarity threshold setting, is only used from its default
+func TestTermMatchFuzzy(t *testing.T) {
+	tests := []struct {
+		threshold float64
+		operator  bool
+	}{
+		{threshold: 0.1, operator: false},
+		{threshold: constDefaultSimilarity, operator: true},
+		{threshold: 0.6, operator: true},
+	}
+
+	client := newTestClient(t, constDialectPostgres)
+	for _, test := range tests {
+		searchData := &searchData{
+			searchFilters: []string{"name"},
+			searchFields:  searchFields{"name": {boost: constDefaultBoost, match: matchFuzzy}},
+			fuzzy:         newFuzzy(test.threshold),
+		}
+
+		predicate := client.termMatch(searchData, &searchTerm{value: "joao"}, "name")
+		if strings.Contains(predicate, `"name" % 'joao'`) != test.operator {
+			t.Errorf("threshold %v: %s, expected the %% operator %t", test.threshold, predicate, test.operator)
+		}
+
+		if similarity := fmt.Sprintf(`similarity("name", 'joao') >= %s`, formatNumber(test.threshold)); !strings.Contains(predicate, similarity) {
+			t.Errorf("threshold %v: %s, expected %s", test.threshold, predicate, similarity)
+		}
+	}
+}
+
+// TestRank checks the relevance of the full text search, ranked by the terms that aren't excluded
+func TestRank(t *testing.T) {
+	tests := []struct {
+		name       string
+		search     string
+		searchData *searchData
+		expected   string
+	}{
+		{
+			name:       "tsvector column",
+			search:     `joao "exact phrase" -test`,
+			searchData: &searchData{fullText: newFullText("english", "document")},
+			expected:   `ts_rank("document", websearch_to_tsquery('english'::regconfig, 'joao or "exact phrase"'))`,
+		},
+		{
+			name:       "search fields",
+			search:     "joao OR first_name:o'neil",
+			searchData: &searchData{fullText: newFullText("simple"), searchFilters: []string{"first_name", "last_name"}},
+			expected:   `ts_rank(to_tsvector('simple'::regconfig, COALESCE("first_name", '') || ' ' || COALESCE("last_name", '')), websearch_to_tsquery('simple'::regconfig, 'joao or o''neil'))`,
+		},
+		{
+			name:       "only excluded terms",
+			search:     "-test",
+			searchData: &searchData{fullText: newFullText("english", "document")},
+			expected:   "",
+		},
+		{
+			name:       "without a tsvector",
+			search:     "joao",
+			searchData: &searchData{fullText: newFullText("english")},
+			expected:   "",
+		},
+	}
+
+	client := newTestClient(t, constDialectPostgres)
+	for _, test := range tests {
+		test.searchData.searchQuery = parseSearch(test.search, test.searchData.searchFilters)
+		if rank := client.rank(test.searchData); rank != test.expected {
+			t.Errorf("%s: %s, expected %s", test.name, rank, test.expected)
+		}
+	}
+}
+
+// TestScoreFuzzy checks that the boosts of the fuzzy fields on postgres are weighted by the similarity, zero on the null values
+func TestScoreFuzzy(t *testing.T) {
+	searchData := &searchData{
+		searchFilters: []string{"first_name", "last_name"},
+		searchFields:  searchFields{"first_name": {boost: 2, match: matchFuzzy}, "last_name": {boost: 1, match: matchExact}},
+		fuzzy:         newFuzzy(),
+		searchQuery:   parseSearch("joa -x", []string{"first_name", "last_name"}),
+	}
+
+	expected := `(2 * COALESCE(similarity("first_name", 'joa'), 0) + CASE WHEN "last_name" ILIKE 'joa' ESCAPE '\' THEN 1 ELSE 0 END)`
+	if score := newTestClient(t, constDialectPostgres).score(searchData); score != expected {
+		t.Errorf("score %s, expected %s", score, expected)
+	}
+}
@@ -0,0 +1,360 @@
+package search
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	goerrors "errors"
+	"fmt"
+	"reflect"
+	"sort"
+	"testing"
+
+	"github.com/joaosoft/dbr"
+)
+
+type testPerson struct {
+	IdPerson  int    `json:"id_person" db:"id_person" search:"filter,sort"`
+	FirstName string `json:"first_name" db:"first_name" search:"filter,search,sort,boost=2,match=prefix"`
+	LastName  string `json:"last_name" db:"last_name" search:"filter,search,sort"`
+	Age       int    `json:"age" db:"age" search:"filter,sort"`
+}
+
+// newPersons creates a sqlite database with the person table of the tests, ordered by id
+func newPersons(t *testing.T) *dbr.Dbr {
+	t.Helper()
+
+	db := newSqlite(t)
+	if _, err := db.Execute("CREATE TABLE person (id_person INTEGER, first_name TEXT, last_name TEXT, age INTEGER)").Exec(); err != nil {
+		t.Fatal(err)
+	}
+
+	persons := []*testPerson{
+		{IdPerson: 1, FirstName: "joao", LastName: "ribeiro", Age: 30},
+		{IdPerson: 2, FirstName: "maria", LastName: "silva", Age: 25},
+		{IdPerson: 3, FirstName: "jose", LastName: "santos", Age: 40},
+		{IdPerson: 4, FirstName: "ana", LastName: "joanes", Age: 35},
+		{IdPerson: 5, FirstName: "rui", LastName: "costa", Age: 30},
+	}
+
+	for _, person := range persons {
+		if _, err := db.Insert().Into("person").Columns("id_person", "first_name", "last_name", "age").Record(person).Exec(); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	return db
+}
+
+func TestPredicate(t *testing.T) {
+	tests := []struct {
+		dialect   string
+		condition *condition
+		expected  string
+	}{
+		{
+			dialect:   constDialectPostgres,
+			condition: &condition{column: "name", operator: operatorEqual, values: []string{"x' OR '1'='1"}},
+			expected:  `"name" = 'x'' OR ''1''=''1'`,
+		},
+		{
+			dialect:   constDialectPostgres,
+			condition: &condition{column: "name", operator: operatorNotEqual, values: []string{"'; DROP TABLE person;--"}},
+			expected:  `"name" <> '''; DROP TABLE person;--'`,
+		},
+		{
+			dialect:   constDialectPostgres,
+			condition: &condition{column: "person.name", operator: operatorIn, values: []string{"a?", "b'", "?c"}},
+			expected:  `"person"."name" IN (('a' || CHR(63) || ''), 'b''', ('' || CHR(63) || 'c'))`,
+		},
+		{
+			dialect:   constDialectPostgres,
+			condition: &condition{column: "age", operator: operatorBetween, values: []string{"1", "2"}, parsed: []interface{}{1, 2}},
+			expected:  `"age" BETWEEN 1 AND 2`,
+		},
+		{
+			dialect:   constDialectPostgres,
+			condition: &condition{column: "name", operator: operatorLike, values: []string{"50%_?"}},
+			expected:  `"name" LIKE ('%50\%\_' || CHR(63) || '%') ESCAPE '\'`,
+		},
+		{
+			dialect:   constDialectMysql,
+			condition: &condition{column: "name", operator: operatorEqual, values: []string{`\' OR 1=1 -- `}},
+			expected:  "`name` = '\\\\\\' OR 1=1 -- '",
+		},
+		{
+			dialect:   constDialectMysql,
+			condition: &condition{column: "name", operator: operatorLike, values: []string{`a\b?`}},
+			expected:  "`name` LIKE CONCAT('%a\\\\\\\\b', CHAR(63 USING utf8mb4), '%') ESCAPE '\\\\'",
+		},
+		{
+			dialect: constDialectMysql,
+			condition: &condition{column: "name", operator: operatorOr, children: conditions{
+				{column: "name", operator: operatorEqual, values: []string{"?"}},
+				{column: "name", operator: operatorIsNull, values: []string{"true"}},
+			}},
+			expected: "(`name` = CONCAT('', CHAR(63 USING utf8mb4), '') OR `name` IS NULL)",
+		},
+		{
+			dialect:   constDialectSqlite,
+			condition: &condition{column: "name", operator: operatorEqual, values: []string{"a?b'c"}},
+			expected:  `"name" = ('a' || CHAR(63) || 'b''c')`,
+		},
+	}
+
+	for _, test := range tests {
+		client := newTestClient(t, test.dialect)
+		if predicate := client.predicate(test.condition); predicate != test.expected {
+			t.Errorf("%s predicate = %s, expected %s", test.dialect, predicate, test.expected)
+		}
+
+		// dbr doesn't find placeholders to replace on the built query
+		client.where(test.condition)
+		if _, err := client.Build(); err != nil {
+			t.Errorf("%s predicate %s: %s", test.dialect, test.expected, err)
+		}
+	}
+}
+
+func TestDecodeCursor(t *testing.T) {
+	tests := []struct {
+		values string
+		valid  bool
+	}{
+		{values: `{"v":["joao",10,1.5,true]}`, valid: true},
+		{values: `{"v":[null,1]}`, valid: true},
+		{values: `{"v":[["1) OR 1=1 --"]]}`, valid: false},
+		{values: `{"v":[{"a":"1) OR 1=1 --"}]}`, valid: false},
+		{values: `{"v":[]}`, valid: false},
+		{values: `{"v":"joao"}`, valid: false},
+		{values: `[1]`, valid: false},
+	}
+
+	for _, test := range tests {
+		token := base64.RawURLEncoding.EncodeToString([]byte(test.values))
+
+		_, err := decodeCursor(token)
+		if test.valid && err != nil {
+			t.Errorf("cursor %s: unexpected error %s", test.values, err)
+		}
+
+		var invalidCursor *InvalidCursorError
+		if !test.valid && !goerrors.As(err, &invalidCursor) {
+			t.Errorf("cursor %s: expected an invalid cursor error, got %v", test.values, err)
+		}
+	}
+
+	if _, err := decodeCursor("not a cursor"); err == nil {
+		t.Error("cursor not encoded: expected an invalid cursor error")
+	}
+}
+
+func TestSeekPredicate(t *testing.T) {
+	tests := []struct {
+		dialect  string
+		orders   orders
+		values   []interface{}
+		expected string
+	}{
+		{
+			dialect:  constDialectPostgres,
+			orders:   orders{{column: "points", direction: orderAsc}, {column: "id", direction: orderAsc}},
+			values:   []interface{}{json.Number("10"), json.Number("3")},
+			expected: `("points", "id") > ('10', '3')`,
+		},
+		{
+			dialect:  constDialectPostgres,
+			orders:   orders{{column: "points", direction: orderDesc}, {column: "id", direction: orderAsc}},
+			values:   []interface{}{json.Number("10"), json.Number("3")},
+			expected: `(("points" < '10') OR ("points" = '10' AND ("id" > '3' OR "id" IS NULL)))`,
+		},
+		{
+			dialect:  constDialectPostgres,
+			orders:   orders{{column: "points", direction: orderAsc}, {column: "id", direction: orderAsc}},
+			values:   []interface{}{nil, json.Number("3")},
+			expected: `(("points" IS NULL AND ("id" > '3' OR "id" IS NULL)))`,
+		},
+		{
+			dialect:  constDialectPostgres,
+			orders:   orders{{column: "points", direction: orderDesc}, {column: "id", direction: orderDesc}},
+			values:   []interface{}{nil, json.Number("3")},
+			expected: `(("points" IS NOT NULL) OR ("points" IS NULL AND "id" < '3'))`,
+		},
+		{
+			dialect:  constDialectPostgres,
+			orders:   orders{{column: "points", direction: orderAsc}},
+			values:   []interface{}{nil},
+			expected: `1 = 0`,
+		},
+		{
+			dialect:  constDialectMysql,
+			orders:   orders{{column: "points", direction: orderAsc}, {column: "name", direction: orderAsc}},
+			values:   []interface{}{nil, "a'?"},
+			expected: "((`points` IS NOT NULL) OR (`points` IS NULL AND `name` > CONCAT('a\\'', CHAR(63 USING utf8mb4), '')))",
+		},
+		{
+			dialect:  constDialectSqlite,
+			orders:   orders{{column: "points", direction: orderDesc}, {column: "id", direction: orderAsc}},
+			values:   []interface{}{nil, json.Number("3")},
+			expected: `(("points" IS NULL AND "id" > '3'))`,
+		},
+	}
+
+	for _, test := range tests {
+		if predicate := newTestClient(t, test.dialect).seekPredicate(test.orders, test.values); predicate != test.expected {
+			t.Errorf("%s %v: %s, expected %s", test.dialect, test.values, predicate, test.expected)
+		}
+	}
+}
+
+type testScore struct {
+	Id     int  `json:"id" db:"id"`
+	Points *int `json:"points" db:"points"`
+}
+
+// TestCursorPaginationNulls pages with the cursors over a sort column with nulls, visiting each row once
+func TestCursorPaginationNulls(t *testing.T) {
+	db := newSqlite(t)
+	if _, err := db.Execute("CREATE TABLE score (id INTEGER, points INTEGER)").Exec(); err != nil {
+		t.Fatal(err)
+	}
+
+	points := []string{"10", "NULL", "5", "NULL", "10", "7", "NULL"}
+	for i, value := range points {
+		if _, err := db.Execute(fmt.Sprintf("INSERT INTO score (id, points) VALUES (%d, %s)", i+1, value)).Exec(); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		sort     string
+		expected []int
+	}{
+		{sort: "points", expected: []int{2, 4, 7, 3, 6, 1, 5}},
+		{sort: "-points", expected: []int{1, 5, 6, 3, 2, 4, 7}},
+	}
+
+	for _, test := range tests {
+		ids := make([]int, 0)
+		cursor := ""
+		for page := 0; page < len(points); page++ {
+			rows := make([]*testScore, 0)
+			query := map[string]string{constSort: test.sort, constSize: "2"}
+			if cursor != "" {
+				query[constCursor] = cursor
+			}
+
+			result, errs := (&Search{}).NewDatabaseSearch(db.Select("*").From("score")).
+				Sortable("points", "points").
+				WithCursorPagination().
+				Tiebreaker("id").
+				Query(query).
+				Bind(&rows).
+				Exec()
+			if len(errs) > 0 {
+				t.Fatalf("sort %s: %v", test.sort, errs)
+			}
+
+			for _, row := range rows {
+				ids = append(ids, row.Id)
+			}
+
+			if result.Pagination.NextCursor == nil {
+				break
+			}
+			cursor = *result.Pagination.NextCursor
+		}
+
+		if !reflect.DeepEqual(ids, test.expected) {
+			t.Errorf("sort %s: %v, expected %v", test.sort, ids, test.expected)
+		}
+	}
+}
+
+// TestSearchSqlite searches the items with the syntax of the search, escaping the like wildcards of the terms
+func TestSearchSqlite(t *testing.T) {
+	tests := []struct {
+		search   string
+		expected []string
+	}{
+		{search: "100%", expected: []string{"100%"}},
+		{search: "a_b", expected: []string{"a_b"}},
+		{search: "joao", expected: []string{"JOAO ribeiro", "Joao"}},
+		{search: `"joao ribeiro"`, expected: []string{"JOAO ribeiro"}},
+		{search: "joao -ribeiro", expected: []string{"Joao"}},
+		{search: "percent OR axb", expected: []string{"100 percent", "axb"}},
+		{search: "c:", expected: []string{"c:/temp", `c:\temp`}},
+	}
+
+	db := newSqlite(t)
+	for _, test := range tests {
+		items := make([]*item, 0)
+		_, errs := (&Search{}).NewDatabaseSearch(db.Select("value").From("item")).
+			SearchFilters("value").
+			Query(map[string]string{constSearch: test.search}).
+			Bind(&items).
+			Exec()
+		if len(errs) > 0 {
+			t.Fatalf("search %s: %v", test.search, errs)
+		}
+
+		values := make([]string, 0, len(items))
+		for _, item := range items {
+			values = append(values, item.Value)
+		}
+		sort.Strings(values)
+
+		if !reflect.DeepEqual(values, test.expected) {
+			t.Errorf("search %s: %q, expected %q", test.search, values, test.expected)
+		}
+	}
+}
+
+func TestFullTextDialect(t *testing.T) {
+	_, errs := (&Search{}).NewDatabaseSearch(newPersons(t).Select("*").From("person")).
+		FullTextSearch("english").
+		SearchFilters("first_name").
+		Query(map[string]string{constSearch: "joao"}).
+		Bind(&[]*testPerson{}).
+		Exec()
+	if len(errs) != 1 || !goerrors.Is(errs[0], ErrorFullTextDialect) {
+		t.Errorf("errors %v, expected %v", errs, ErrorFullTextDialect)
+	}
+}
+
+// TestRelevanceSqlite sorts by the sum of the boosts of the search fields matching the terms
+func TestRelevanceSqlite(t *testing.T) {
+	tests := []struct {
+		search   string
+		expected []int
+	}{
+		{search: "jo", expected: []int{1, 3, 4}},
+		{search: "jo OR s", expected: []int{3, 1, 4, 2, 5}},
+		{search: "jo es", expected: []int{4}},
+		{search: "ana", expected: []int{4}},
+		{search: "na", expected: []int{}},
+	}
+
+	db := newPersons(t)
+	for _, test := range tests {
+		items := make([]*testPerson, 0)
+		_, errs := (&Search{}).NewDatabaseSearch(db.Select("*").From("person")).
+			SearchField("first_name", 2, matchPrefix).
+			SearchField("last_name", 1, matchContains).
+			Query(map[string]string{constSearch: test.search, constSort: "-relevance"}).
+			OrderBy("id_person", orderAsc).
+			Bind(&items).
+			Exec()
+		if len(errs) > 0 {
+			t.Fatalf("search %s: %v", test.search, errs)
+		}
+
+		ids := make([]int, 0, len(items))
+		for _, item := range items {
+			ids = append(ids, item.IdPerson)
+		}
+
+		if !reflect.DeepEqual(ids, test.expected) {
+			t.Errorf("search %s: %v, expected %v", test.search, ids, test.expected)
+		}
+	}
+}
@@ -1,7 +1,9 @@
 package search
 
 import (
+	"context"
 	"reflect"
+	"strings"
 
 	"github.com/joaosoft/elastic"
 )
@@ -14,72 +16,129 @@ func (search *Search) newElasticClient(stmt *elastic.SearchService) *elasticClie
 	return &elasticClient{SearchService: stmt}
 }
 
-func (client *elasticClient) Exec(searchData *searchData) (int, error) {
+func (client *elasticClient) backend() string {
+	return constBackendElastic
+}
+
+func (client *elasticClient) Exec(ctx context.Context, searchData *searchData) (int, error) {
 	// query
-	terms := make([]elastic.Query, 0)
-	for key, value := range searchData.query {
-		terms = append(terms, elastic.NewTerm(key, value))
+	query := newElasticBool()
+	for _, condition := range searchData.query {
+		client.where(query, condition)
 	}
-	client.Query(elastic.NewBool().Must(terms...))
 
 	// search
-	lenQ := len(searchData.searchFilters)
-	if searchData.search != nil && lenQ > 0 {
-		queryString := elastic.NewQueryString(*searchData.search)
-		for _, filter := range searchData.searchFilters {
-			queryString.Fields(filter)
-		}
-		client.Query(queryString)
+	if search := client.searchQuery(searchData); search != nil {
+		query.Must(search)
 	}
+	body := newElasticBody().Query(query)
 
 	// pagination
 	total := 0
-	if searchData.hasPagination {
-		response, err := client.Count()
-		if err != nil {
-			return 0, err
+	orders := searchData.orders
+	switch searchData.paginationMode {
+	case paginationModeCursor:
+		if len(orders) == 0 {
+			return 0, ErrorCursorWithoutOrder
 		}
 
-		if response.OnError != nil || response.OnErrorDocumentNotFound != nil {
-			return 0, nil
+		if searchData.cursor != nil {
+			if searchData.cursor.Backward {
+				orders = orders.reverse()
+			}
+
+			if len(searchData.cursor.Values) != len(orders) {
+				return 0, newInvalidCursorError(searchData.cursor.encode())
+			}
+
+			body.SearchAfter(searchData.cursor.Values...)
 		}
 
-		if response.Count == 0 {
-			return 0, nil
+		if searchData.size > 0 {
+			body.Size(searchData.size + 1)
 		}
+	default:
+		if searchData.hasPagination {
+			countBody, err := newElasticBody().Query(query).Bytes()
+			if err != nil {
+				return 0, err
+			}
 
-		total = int(response.Count)
-	}
+			response, err := client.request(ctx, constElasticCount, countBody)
+			if err != nil {
+				return 0, err
+			}
 
-	if searchData.size > 0 {
-		client.Size(searchData.size)
-	}
+			searchData.hasTotal = true
+			searchData.isTotalExact = true
+
+			if response.Count == 0 {
+				return 0, nil
+			}
+
+			total = response.Count
+		}
+
+		if searchData.size > 0 {
+			body.Size(searchData.size)
+		}
 
-	if searchData.page > 0 {
-		client.From((searchData.page - 1) * searchData.size)
+		if searchData.page > 0 {
+			body.From((searchData.page - 1) * searchData.size)
+		}
 	}
 
 	// order by
 	sorts := make([]*elastic.SortField, 0)
-	for _, order := range searchData.orders {
+	for _, order := range orders {
+		column := order.column
+		if column == constRelevanceColumn {
+			column = constElasticScore
+		}
+
 		switch order.direction {
 		case orderAsc:
-			sorts = append(sorts, elastic.NewSortField(order.column, elastic.OrderAsc))
+			sorts = append(sorts, elastic.NewSortField(column, elastic.OrderAsc))
 		case orderDesc:
-			sorts = append(sorts, elastic.NewSortField(order.column, elastic.OrderDesc))
+			sorts = append(sorts, elastic.NewSortField(column, elastic.OrderDesc))
 		}
 	}
 
-	if _, err := client.Object(searchData.object).Query(elastic.NewSort(sorts...)).Search(); err != nil {
+	searchBody, err := body.Sort(sorts...).Bytes()
+	if err != nil {
+		return 0, err
+	}
+
+	response, err := client.request(ctx, constElasticSearch, searchBody)
+	if err != nil {
+		return 0, err
+	}
+
+	if err = response.sources(searchData.object); err != nil {
 		return 0, err
 	}
 
+	if searchData.paginationMode == paginationModeCursor {
+		// the cursors are built from the sort values of the hits, the relevance isn't on the sources
+		searchData.setCursors(func(index int, _ reflect.Value) ([]interface{}, bool) {
+			hit := response.Hits.Hits[index]
+			return hit.Sort, len(hit.Sort) == len(searchData.orders)
+		})
+
+		// the hits total is capped by the index settings, only exact when elastic says so
+		total, searchData.isTotalExact = response.total()
+		searchData.hasTotal = true
+	}
+
 	// Metadata
 	if searchData.hasMetadata {
 		for _, item := range searchData.metadata {
 			// function
 			if item.function != nil {
-				if err := item.function(reflect.ValueOf(searchData.object).Elem().Interface(), item.object, searchData.metadata); err != nil {
+				err = run(ctx, func() error {
+					return item.function(reflect.ValueOf(searchData.object).Elem().Interface(), item.object, searchData.metadata)
+				})
+				if err != nil {
 					return 0, err
 				}
 			}
@@ -87,7 +146,11 @@ func (client *elasticClient) Exec(searchData *searchData) (int, error) {
 			// statement
 			if item.stmt != nil {
 				if stmt, ok := item.stmt.(*elastic.SearchService); ok {
-					if _, err := stmt.Object(item.object).Search(); err != nil {
+					err = run(ctx, func() error {
+						_, err := stmt.Object(item.object).Search()
+						return err
+					})
+					if err != nil {
 						return 0, err
 					}
 				}
@@ -95,6 +158,131 @@ func (client *elasticClient) Exec(searchData *searchData) (int, error) {
 		}
 	}
 
-	_, err := client.Object(searchData.object).Search()
-	return total, err
+	return total, nil
+}
+
+// searchQuery translates the parsed search, matching each term on its scoped field or on all
+// the search fields, without the lucene syntax of the query_string query
+func (client *elasticClient) searchQuery(searchData *searchData) elastic.Query {
+	if len(searchData.searchFilters) == 0 {
+		return nil
+	}
+
+	groups := make([]elastic.Query, 0, len(searchData.searchQuery))
+	for _, group := range searchData.searchQuery {
+		query := newElasticBool()
+		for _, term := range group {
+			match := client.termQuery(searchData, term)
+			if term.excluded {
+				query.MustNot(match)
+			} else {
+				query.Must(match)
+			}
+		}
+		groups = append(groups, query)
+	}
+
+	switch len(groups) {
+	case 0:
+		return nil
+	case 1:
+		return groups[0]
+	}
+
+	return newElasticBool().Should(groups...)
+}
+
+// termQuery matches the term on its fields grouped by their match strategy, with their boosts,
+// the exact fields are matched with a term query on their not analyzed values
+func (client *elasticClient) termQuery(searchData *searchData, term *searchTerm) elastic.Query {
+	strategies := make([]match, 0)
+	fields := make(map[match][]string)
+	for _, name := range term.fields(searchData.searchFilters) {
+		boost, match := searchData.searchFields.field(name).boost, searchData.matchOf(name)
+		if _, ok := fields[match]; !ok {
+			strategies = append(strategies, match)
+		}
+
+		if match == matchExact || boost == constDefaultBoost {
+			fields[match] = append(fields[match], name)
+		} else {
+			fields[match] = append(fields[match], name+"^"+formatNumber(boost))
+		}
+	}
+
+	queries := make([]elastic.Query, 0)
+	for _, strategy := range strategies {
+		switch strategy {
+		case matchExact:
+			for _, name := range fields[strategy] {
+				queries = append(queries, newElasticTerm(name, term.value).Boost(searchData.searchFields.field(name).boost))
+			}
+		case matchPrefix:
+			queries = append(queries, newElasticMultiMatch(term.value, fields[strategy]...).Type("phrase_prefix"))
+		case matchFuzzy:
+			if term.phrase {
+				// the phrases don't support fuzziness
+				queries = append(queries, newElasticMultiMatch(term.value, fields[strategy]...).Type("phrase"))
+			} else {
+				queries = append(queries, newElasticMultiMatch(term.value, fields[strategy]...).Operator("and").Fuzziness("AUTO"))
+			}
+		default:
+			if term.phrase {
+				queries = append(queries, newElasticMultiMatch(term.value, fields[strategy]...).Type("phrase"))
+			} else {
+				queries = append(queries, newElasticMultiMatch(term.value, fields[strategy]...).Operator("and"))
+			}
+		}
+	}
+
+	if len(queries) == 1 {
+		return queries[0]
+	}
+
+	return newElasticBool().Should(queries...)
+}
+
+func (client *elasticClient) where(query *elasticBool, condition *condition) {
+	if predicate := client.predicate(condition); predicate != nil {
+		query.Must(predicate)
+	}
+}
+
+func (client *elasticClient) predicate(condition *condition) elastic.Query {
+	values := condition.interfaces()
+
+	switch condition.operator {
+	case operatorEqual:
+		return newElasticTerm(condition.column, values[0])
+	case operatorNotEqual:
+		return newElasticBool().MustNot(newElasticTerm(condition.column, values[0]))
+	case operatorGreater:
+		return newElasticRange(condition.column).Gt(values[0])
+	case operatorGreaterOrEqual:
+		return newElasticRange(condition.column).Gte(values[0])
+	case operatorLess:
+		return newElasticRange(condition.column).Lt(values[0])
+	case operatorLessOrEqual:
+		return newElasticRange(condition.column).Lte(values[0])
+	case operatorIn:
+		return newElasticTerms(condition.column, values...)
+	case operatorBetween:
+		return newElasticRange(condition.column).Gte(values[0]).Lte(values[1])
+	case operatorLike:
+		value := strings.NewReplacer(`\`, `\\`, "*", `\*`, "?", `\?`).Replace(condition.values[0])
+		return newElasticWildcard(condition.column, "*"+value+"*")
+	case operatorIsNull:
+		if condition.isNull() {
+			return newElasticBool().MustNot(newElasticExists(condition.column))
+		}
+		return newElasticExists(condition.column)
+	case operatorOr:
+		query := newElasticBool()
+		for _, child := range condition.children {
+			query.Should(client.predicate(child))
+		}
+		return query
+	}
+
+	return nil
 }
@@ -0,0 +1,216 @@
+package search
+
+import (
+	"encoding/json"
+
+	"github.com/joaosoft/elastic"
+)
+
+type elasticBool struct {
+	mappings map[string][]elastic.Query
+}
+
+func newElasticBool() *elasticBool {
+	return &elasticBool{
+		mappings: make(map[string][]elastic.Query),
+	}
+}
+
+func (b *elasticBool) Must(value ...elastic.Query) *elasticBool {
+	b.mappings["must"] = append(b.mappings["must"], value...)
+	return b
+}
+
+func (b *elasticBool) MustNot(value ...elastic.Query) *elasticBool {
+	b.mappings["must_not"] = append(b.mappings["must_not"], value...)
+	return b
+}
+
+func (b *elasticBool) Should(value ...elastic.Query) *elasticBool {
+	b.mappings["should"] = append(b.mappings["should"], value...)
+	return b
+}
+
+func (b *elasticBool) Data() interface{} {
+	mappings := make(map[string][]interface{})
+	for key, queries := range b.mappings {
+		for _, query := range queries {
+			mappings[key] = append(mappings[key], query.Data())
+		}
+	}
+
+	return map[string]interface{}{"bool": mappings}
+}
+
+type elasticRange struct {
+	mappings map[string]interface{}
+	field    string
+}
+
+func newElasticRange(field string) *elasticRange {
+	return &elasticRange{
+		mappings: make(map[string]interface{}),
+		field:    field,
+	}
+}
+
+func (r *elasticRange) Gt(value interface{}) *elasticRange {
+	r.mappings["gt"] = value
+	return r
+}
+
+func (r *elasticRange) Gte(value interface{}) *elasticRange {
+	r.mappings["gte"] = value
+	return r
+}
+
+func (r *elasticRange) Lt(value interface{}) *elasticRange {
+	r.mappings["lt"] = value
+	return r
+}
+
+func (r *elasticRange) Lte(value interface{}) *elasticRange {
+	r.mappings["lte"] = value
+	return r
+}
+
+func (r *elasticRange) Data() interface{} {
+	return map[string]interface{}{"range": map[string]interface{}{r.field: r.mappings}}
+}
+
+type elasticTerms struct {
+	field  string
+	values []interface{}
+}
+
+func newElasticTerms(field string, values ...interface{}) *elasticTerms {
+	return &elasticTerms{field: field, values: values}
+}
+
+func (t *elasticTerms) Data() interface{} {
+	return map[string]interface{}{"terms": map[string]interface{}{t.field: t.values}}
+}
+
+type elasticExists struct {
+	field string
+}
+
+func newElasticExists(field string) *elasticExists {
+	return &elasticExists{field: field}
+}
+
+func (e *elasticExists) Data() interface{} {
+	return map[string]interface{}{"exists": map[string]interface{}{"field": e.field}}
+}
+
+type elasticWildcard struct {
+	field string
+	value string
+}
+
+func newElasticWildcard(field string, value string) *elasticWildcard {
+	return &elasticWildcard{field: field, value: value}
+}
+
+func (w *elasticWildcard) Data() interface{} {
+	return map[string]interface{}{"wildcard": map[string]interface{}{w.field: map[string]interface{}{"value": w.value}}}
+}
+
+// elasticBody is the body of the search request, built here since the search service merges
+// all its queries under the query key, where the sort and search_after aren't accepted
+type elasticBody struct {
+	mappings map[string]interface{}
+}
+
+func newElasticBody() *elasticBody {
+	return &elasticBody{
+		mappings: make(map[string]interface{}),
+	}
+}
+
+func (b *elasticBody) Query(query elastic.Query) *elasticBody {
+	b.mappings["query"] = query.Data()
+	return b
+}
+
+func (b *elasticBody) Sort(fields ...*elastic.SortField) *elasticBody {
+	if len(fields) == 0 {
+		return b
+	}
+
+	sorts := make([]interface{}, len(fields))
+	for i, field := range fields {
+		sorts[i] = field.Data()
+	}
+	b.mappings["sort"] = sorts
+	return b
+}
+
+func (b *elasticBody) SearchAfter(values ...interface{}) *elasticBody {
+	b.mappings["search_after"] = values
+	return b
+}
+
+func (b *elasticBody) Size(size int) *elasticBody {
+	b.mappings["size"] = size
+	return b
+}
+
+func (b *elasticBody) From(from int) *elasticBody {
+	b.mappings["from"] = from
+	return b
+}
+
+func (b *elasticBody) Bytes() ([]byte, error) {
+	return json.Marshal(b.mappings)
+}
+
+type elasticMultiMatch struct {
+	mappings map[string]interface{}
+}
+
+func newElasticMultiMatch(query string, fields ...string) *elasticMultiMatch {
+	return &elasticMultiMatch{
+		mappings: map[string]interface{}{"query": query, "fields": fields},
+	}
+}
+
+func (m *elasticMultiMatch) Type(value string) *elasticMultiMatch {
+	m.mappings["type"] = value
+	return m
+}
+
+func (m *elasticMultiMatch) Operator(value string) *elasticMultiMatch {
+	m.mappings["operator"] = value
+	return m
+}
+
+func (m *elasticMultiMatch) Fuzziness(value string) *elasticMultiMatch {
+	m.mappings["fuzziness"] = value
+	return m
+}
+
+func (m *elasticMultiMatch) Data() interface{} {
+	return map[string]interface{}{"multi_match": m.mappings}
+}
+
+type elasticTerm struct {
+	mappings map[string]interface{}
+	field    string
+}
+
+func newElasticTerm(field string, value interface{}) *elasticTerm {
+	return &elasticTerm{
+		mappings: map[string]interface{}{"value": value},
+		field:    field,
+	}
+}
+
+func (t *elasticTerm) Boost(value float64) *elasticTerm {
+	t.mappings["boost"] = value
+	return t
+}
+
+func (t *elasticTerm) Data() interface{} {
+	return map[string]interface{}{"term": map[string]interface{}{t.field: t.mappings}}
+}
@@ -0,0 +1,150 @@
+package search
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"reflect"
+	"strings"
+)
+
+const constContentTypeJSON = "application/json"
+
+// elasticResponse is the response of a search or count, decoded here since the search service
+// expects the hits total as a number, that is an object since elastic 7, and drops the sort values of the hits
+type elasticResponse struct {
+	Hits struct {
+		Total json.RawMessage `json:"total"`
+		Hits  []*elasticHit   `json:"hits"`
+	} `json:"hits"`
+	Count int `json:"count"`
+}
+
+type elasticHit struct {
+	ID     string          `json:"_id"`
+	Source json.RawMessage `json:"_source"`
+	Sort   []interface{}   `json:"sort"`
+}
+
+type elasticTotal struct {
+	Value    int    `json:"value"`
+	Relation string `json:"relation"`
+}
+
+// total returns the hits total and if it's exact, the total is a number before elastic 7
+// and an object with its relation to the real total since then
+func (response *elasticResponse) total() (int, bool) {
+	total := &elasticTotal{}
+	if err := json.Unmarshal(response.Hits.Total, total); err == nil {
+		return total.Value, total.Relation == "eq"
+	}
+
+	var value int
+	json.Unmarshal(response.Hits.Total, &value)
+	return value, true
+}
+
+// sources decodes the sources of the hits on the object
+func (response *elasticResponse) sources(object interface{}) error {
+	sources := make([]json.RawMessage, len(response.Hits.Hits))
+	for i, hit := range response.Hits.Hits {
+		sources[i] = hit.Source
+	}
+
+	data, err := json.Marshal(sources)
+	if err != nil {
+		return err
+	}
+
+	return json.Unmarshal(data, object)
+}
+
+// elasticReason returns the reason of the error of the body, or the body when it isn't an elastic error
+func elasticReason(data []byte) string {
+	body := &struct {
+		Error struct {
+			Type   string `json:"type"`
+			Reason string `json:"reason"`
+		} `json:"error"`
+	}{}
+
+	if err := json.Unmarshal(data, body); err != nil || body.Error.Type == "" {
+		return strings.TrimSpace(string(data))
+	}
+
+	return fmt.Sprintf("%s: %s", body.Error.Type, body.Error.Reason)
+}
+
+// request sends the body to the operation of the indexes of the search service, interrupted when the context is done
+func (client *elasticClient) request(ctx context.Context, operation string, body []byte) (*elasticResponse, error) {
+	endpoint, index := client.target()
+	if endpoint == "" || index == "" {
+		return nil, fmt.Errorf("the elastic search requires an endpoint and an index")
+	}
+
+	request, err := http.NewRequestWithContext(ctx, http.MethodPost, fmt.Sprintf("%s/%s/%s", strings.TrimSuffix(endpoint, "/"), index, operation), bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	request.Header.Set(constHeaderContentType, constContentTypeJSON)
+
+	httpResponse, err := http.DefaultClient.Do(request)
+	if err != nil {
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		return nil, newBackendUnavailableError(constBackendElastic, err)
+	}
+	defer httpResponse.Body.Close()
+
+	data, err := io.ReadAll(httpResponse.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	if httpResponse.StatusCode >= http.StatusBadRequest {
+		err := newBackendError(constBackendElastic, httpResponse.StatusCode, elasticReason(data))
+		if httpResponse.StatusCode >= http.StatusInternalServerError || httpResponse.StatusCode == http.StatusTooManyRequests {
+			// overloaded or failing, as the unreachable ones
+			return nil, newBackendUnavailableError(constBackendElastic, err)
+		}
+		return nil, err
+	}
+
+	// the sort values are kept as numbers, the long values can't be read as floats
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+
+	response := &elasticResponse{}
+	if err = decoder.Decode(response); err != nil {
+		return nil, err
+	}
+
+	return response, nil
+}
+
+// target returns the endpoint of the client and the indexes of the search service, that they don't expose
+func (client *elasticClient) target() (string, string) {
+	service := reflect.ValueOf(client.SearchService).Elem()
+
+	indexes := service.FieldByName("index")
+	names := make([]string, indexes.Len())
+	for i := range names {
+		names[i] = indexes.Index(i).String()
+	}
+
+	elastic := service.FieldByName("client")
+	if elastic.IsNil() {
+		return "", ""
+	}
+
+	config := elastic.Elem().FieldByName("config")
+	if config.IsNil() {
+		return "", ""
+	}
+
+	return config.Elem().FieldByName("Endpoint").String(), strings.Join(names, ",")
+}
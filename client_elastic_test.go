@@ -0,0 +1,300 @@
+package search
+
+import (
+	"encoding/json"
+	goerrors "errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/joaosoft/elastic"
+)
+
+// newElastic starts an elastic 7 like server answering the searches and counts with the given status and responses
+func newElastic(t *testing.T, status int, responses map[string]string) (*elastic.Elastic, *[]map[string]interface{}) {
+	t.Helper()
+
+	bodies := make([]map[string]interface{}, 0)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		data, _ := io.ReadAll(r.Body)
+		body := make(map[string]interface{})
+		json.Unmarshal(data, &body)
+		bodies = append(bodies, body)
+
+		response, ok := responses[r.URL.Path]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Header().Set(constHeaderContentType, constContentTypeJSON)
+		w.WriteHeader(status)
+		fmt.Fprint(w, response)
+	}))
+	t.Cleanup(server.Close)
+
+	client, err := elastic.NewElastic(elastic.WithConfiguration(&elastic.ElasticConfig{Endpoint: server.URL}))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return client, &bodies
+}
+
+func TestElasticCursorPagination(t *testing.T) {
+	tests := []struct {
+		name     string
+		total    string
+		expected int
+		exact    bool
+	}{
+		{name: "elastic 7 exact total", total: `{"value": 3, "relation": "eq"}`, expected: 3, exact: true},
+		{name: "elastic 7 total lower bound", total: `{"value": 10000, "relation": "gte"}`, expected: 10000, exact: false},
+		{name: "elastic 6 total", total: `3`, expected: 3, exact: true},
+	}
+
+	for _, test := range tests {
+		// the sources don't have the sort values, they are only on the sort of the hits
+		client, bodies := newElastic(t, http.StatusOK, map[string]string{"/person/_search": `{"hits": {"total": ` + test.total + `, "hits": [
+			{"_id": "2", "_source": {"first_name": "maria"}, "sort": [25, 2]},
+			{"_id": "1", "_source": {"first_name": "joao"}, "sort": [30, 1]},
+			{"_id": "5", "_source": {"first_name": "rui"}, "sort": [30, 5]}
+		]}}`})
+
+		rows := make([]*testPerson, 0)
+		result, errs := (&Search{}).NewElasticSearch(client.Search().Index("person")).
+			Sortable("age", "age").
+			WithCursorPagination().
+			Tiebreaker("id_person").
+			Query(map[string]string{constSort: "age", constSize: "2"}).
+			Bind(&rows).
+			Exec()
+		if len(errs) > 0 {
+			t.Fatalf("%s: %v", test.name, errs)
+		}
+
+		if len(rows) != 2 || rows[0].FirstName != "maria" || rows[1].FirstName != "joao" {
+			t.Errorf("%s: rows %+v", test.name, rows)
+		}
+
+		if body := (*bodies)[0]; body["size"] != float64(3) {
+			t.Errorf("%s: size %v, expected 3", test.name, body["size"])
+		}
+
+		if result.Pagination.NextCursor == nil {
+			t.Fatalf("%s: expected a next cursor", test.name)
+		}
+
+		cursor, err := decodeCursor(*result.Pagination.NextCursor)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if expected := []interface{}{json.Number("30"), json.Number("1")}; !reflect.DeepEqual(cursor.Values, expected) {
+			t.Errorf("%s: cursor values %v, expected %v", test.name, cursor.Values, expected)
+		}
+
+		if result.Pagination.Total == nil || *result.Pagination.Total != test.expected || result.Pagination.IsTotalExact != test.exact {
+			t.Errorf("%s: total %v exact %t, expected %d exact %t", test.name, result.Pagination.Total, result.Pagination.IsTotalExact, test.expected, test.exact)
+		}
+	}
+}
+
+func TestElasticOffsetPagination(t *testing.T) {
+	client, bodies := newElastic(t, http.StatusOK, map[string]string{
+		"/person/_count":  `{"count": 5}`,
+		"/person/_search": `{"hits": {"total": {"value": 5, "relation": "eq"}, "hits": [{"_id": "3", "_source": {"first_name": "jose"}}]}}`,
+	})
+
+	rows := make([]*testPerson, 0)
+	result, errs := (&Search{}).NewElasticSearch(client.Search().Index("person")).
+		Query(map[string]string{constPage: "3", constSize: "2"}).
+		Bind(&rows).
+		Exec()
+	if len(errs) > 0 {
+		t.Fatal(errs)
+	}
+
+	if len(rows) != 1 || rows[0].FirstName != "jose" {
+		t.Errorf("rows %+v", rows)
+	}
+
+	if result.Pagination.Total == nil || *result.Pagination.Total != 5 {
+		t.Errorf("total %v, expected 5", result.Pagination.Total)
+	}
+
+	if body := (*bodies)[1]; body["from"] != float64(4) || body["size"] != float64(2) {
+		t.Errorf("from %v size %v, expected 4 and 2", body["from"], body["size"])
+	}
+}
+
+// TestElasticUnavailable checks that an unreachable elastic is an unavailable backend, opening its circuit breaker
+func TestElasticUnavailable(t *testing.T) {
+	client, err := elastic.NewElastic(elastic.WithConfiguration(&elastic.ElasticConfig{Endpoint: "http://127.0.0.1:1"}))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	search := &Search{}
+	search.Reconfigure(WithCircuitBreaker(2, time.Hour))
+
+	for i, expected := range []error{nil, nil, ErrorCircuitOpen} {
+		rows := make([]*testPerson, 0)
+		_, errs := search.NewElasticSearch(client.Search().Index("person")).
+			Bind(&rows).
+			Exec()
+
+		var unavailable *BackendUnavailableError
+		if len(errs) != 1 || !goerrors.As(errs[0], &unavailable) || unavailable.Backend != constBackendElastic {
+			t.Fatalf("search %d: expected an unavailable elastic, got %v", i, errs)
+		}
+
+		if expected != nil && !goerrors.Is(errs[0], expected) {
+			t.Errorf("search %d: %v, expected %v", i, errs[0], expected)
+		}
+
+		if status := StatusCode(errs...); status != http.StatusServiceUnavailable {
+			t.Errorf("search %d: status %d, expected %d", i, status, http.StatusServiceUnavailable)
+		}
+	}
+}
+
+// TestElasticErrorResponse checks that the errors answered by elastic are typed, as unavailable when it's failing or overloaded
+func TestElasticErrorResponse(t *testing.T) {
+	tests := []struct {
+		name        string
+		path        string
+		status      int
+		response    string
+		reason      string
+		unavailable bool
+		httpStatus  int
+	}{
+		{name: "search rejected", path: "/person/_search", status: http.StatusBadRequest, response: `{"error": {"type": "parsing_exception", "reason": "unknown query [foo]"}, "status": 400}`, reason: "parsing_exception: unknown query [foo]", httpStatus: http.StatusBadGateway},
+		{name: "index not found", path: "/person/_search", status: http.StatusNotFound, response: `{"error": {"type": "index_not_found_exception", "reason": "no such index [person]"}, "status": 404}`, reason: "index_not_found_exception: no such index [person]", httpStatus: http.StatusBadGateway},
+		{name: "count failing", path: "/person/_count", status: http.StatusInternalServerError, response: `{"error": {"type": "search_phase_execution_exception", "reason": "all shards failed"}, "status": 500}`, reason: "search_phase_execution_exception: all shards failed", unavailable: true, httpStatus: http.StatusServiceUnavailable},
+		{name: "search overloaded", path: "/person/_search", status: http.StatusTooManyRequests, response: `too many requests`, reason: "too many requests", unavailable: true, httpStatus: http.StatusServiceUnavailable},
+	}
+
+	for _, test := range tests {
+		client, _ := newElastic(t, test.status, map[string]string{test.path: test.response})
+
+		rows := make([]*testPerson, 0)
+		handler := (&Search{}).NewElasticSearch(client.Search().Index("person")).Bind(&rows)
+		if test.path == "/person/_search" {
+			// without pagination it doesn't count
+			handler.WithoutPagination()
+		}
+
+		_, errs := handler.Exec()
+		if len(errs) != 1 {
+			t.Fatalf("%s: errors %v", test.name, errs)
+		}
+
+		var backendErr *BackendError
+		if !goerrors.As(errs[0], &backendErr) || backendErr.Status != test.status || backendErr.Reason != test.reason || backendErr.Backend != constBackendElastic {
+			t.Errorf("%s: %v, expected the backend error %d %s", test.name, errs[0], test.status, test.reason)
+		}
+
+		var unavailable *BackendUnavailableError
+		if goerrors.As(errs[0], &unavailable) != test.unavailable {
+			t.Errorf("%s: unavailable %t, expected %t", test.name, !test.unavailable, test.unavailable)
+		}
+
+		if status := StatusCode(errs...); status != test.httpStatus {
+			t.Errorf("%s: status %d, expected %d", test.name, status, test.httpStatus)
+		}
+	}
+}
+
+func TestElasticSort(t *testing.T) {
+	client, bodies := newElastic(t, http.StatusOK, map[string]string{"/person/_search": `{"hits": {"total": {"value": 0, "relation": "eq"}, "hits": []}}`})
+
+	rows := make([]*testPerson, 0)
+	_, errs := (&Search{}).NewElasticSearch(client.Search().Index("person")).
+		WithoutPagination().
+		Sortable("age", "age").
+		Sortable("name", "first_name.keyword").
+		Query(map[string]string{constSort: "-age,name"}).
+		Bind(&rows).
+		Exec()
+	if len(errs) > 0 {
+		t.Fatal(errs)
+	}
+
+	sort, _ := json.Marshal((*bodies)[0]["sort"])
+	if expected := `[{"age":{"order":"desc"}},{"first_name.keyword":{"order":"asc"}}]`; string(sort) != expected {
+		t.Errorf("sort %s, expected %s", sort, expected)
+	}
+}
+
+// TestElasticSearchFields checks the queries of the search fields, grouped by their match strategy with their boosts
+func TestElasticSearchFields(t *testing.T) {
+	tests := []struct {
+		search   string
+		expected string
+	}{
+		{
+			search:   "jo",
+			expected: `{"bool":{"must":[{"bool":{"must":[{"bool":{"should":[{"multi_match":{"fields":["first_name^2"],"query":"jo","type":"phrase_prefix"}},{"multi_match":{"fields":["last_name"],"operator":"and","query":"jo"}},{"term":{"email":{"boost":3,"value":"jo"}}}]}}]}}]}}`,
+		},
+		{
+			search:   `last_name:"da silva" -email:x`,
+			expected: `{"bool":{"must":[{"bool":{"must":[{"multi_match":{"fields":["last_name"],"query":"da silva","type":"phrase"}}],"must_not":[{"term":{"email":{"boost":3,"value":"x"}}}]}}]}}`,
+		},
+	}
+
+	for _, test := range tests {
+		client, bodies := newElastic(t, http.StatusOK, map[string]string{"/person/_search": `{"hits": {"total": {"value": 0, "relation": "eq"}, "hits": []}}`})
+
+		_, errs := (&Search{}).NewElasticSearch(client.Search().Index("person")).
+			WithoutPagination().
+			SearchField("first_name", 2, matchPrefix).
+			SearchField("last_name", 1, matchContains).
+			SearchField("email", 3, matchExact).
+			Query(map[string]string{constSearch: test.search}).
+			Bind(&[]*testPerson{}).
+			Exec()
+		if len(errs) > 0 {
+			t.Fatal(errs)
+		}
+
+		if query, _ := json.Marshal((*bodies)[0]["query"]); string(query) != test.expected {
+			t.Errorf("search %s: %s, expected %s", test.search, query, test.expected)
+		}
+	}
+}
+
+func TestElasticFuzzy(t *testing.T) {
+	tests := []struct {
+		search   string
+		expected string
+	}{
+		{search: "joa", expected: `{"multi_match":{"fields":["first_name^2","last_name"],"fuzziness":"AUTO","operator":"and","query":"joa"}}`},
+		{search: `"joao ribeiro"`, expected: `{"multi_match":{"fields":["first_name^2","last_name"],"query":"joao ribeiro","type":"phrase"}}`},
+	}
+
+	for _, test := range tests {
+		client, bodies := newElastic(t, http.StatusOK, map[string]string{"/person/_search": `{"hits": {"total": {"value": 0, "relation": "eq"}, "hits": []}}`})
+
+		_, errs := (&Search{}).NewElasticSearch(client.Search().Index("person")).
+			WithoutPagination().
+			SearchField("first_name", 2, matchContains).
+			SearchField("last_name", 1, matchContains).
+			FuzzySearch().
+			Query(map[string]string{constSearch: test.search}).
+			Bind(&[]*testPerson{}).
+			Exec()
+		if len(errs) > 0 {
+			t.Fatal(errs)
+		}
+
+		if query, _ := json.Marshal((*bodies)[0]["query"]); !strings.Contains(string(query), test.expected) {
+			t.Errorf("search %s: %s, expected %s", test.search, query, test.expected)
+		}
+	}
+}
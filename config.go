@@ -14,12 +14,66 @@ type AppConfig struct {
 
 // SearchConfig ...
 type SearchConfig struct {
-	Migration *migration.MigrationConfig `json:"migration"`
-	Log       struct {
+	Migration      *migration.MigrationConfig   `json:"migration"`
+	Timeout        string                       `json:"timeout"`
+	CircuitBreaker *CircuitBreakerConfig        `json:"circuit_breaker"`
+	Searches       map[string]*DefinitionConfig `json:"searches"`
+	Log            struct {
 		Level string `json:"level"`
 	} `json:"log"`
 }
 
+// CircuitBreakerConfig ...
+type CircuitBreakerConfig struct {
+	Threshold int    `json:"threshold"`
+	Cooldown  string `json:"cooldown"`
+}
+
+// DefinitionConfig declares a search built by name with Named, on a database table or an elastic index
+type DefinitionConfig struct {
+	Table     string                   `json:"table"`
+	Index     string                   `json:"index"`
+	Type      string                   `json:"type"`
+	Filters   map[string]*FilterConfig `json:"filters"`
+	Strict    bool                     `json:"strict_filters"`
+	Search    []string                 `json:"search"`
+	Fields    map[string]*FieldConfig  `json:"search_fields"`
+	FullText  *FullTextConfig          `json:"full_text"`
+	Fuzzy     *FuzzyConfig             `json:"fuzzy"`
+	Sortables map[string]string        `json:"sortables"`
+	Order     []string                 `json:"order"`
+	Size      int                      `json:"size"`
+	MaxSize   int                      `json:"max_size"`
+	Breaker   string                   `json:"circuit_breaker"`
+}
+
+// FullTextConfig enables the postgres full text search with the text search configuration (language),
+// over the search fields or the tsvector column
+type FullTextConfig struct {
+	Config string `json:"config"`
+	Column string `json:"column"`
+}
+
+// FieldConfig sets the boost of a search field on the relevance, 1 when empty,
+// and its match strategy (contains, prefix, exact or fuzzy), contains when empty
+type FieldConfig struct {
+	Boost float64 `json:"boost"`
+	Match string  `json:"match"`
+}
+
+// FuzzyConfig enables the typo tolerant search, with the pg_trgm similarity threshold on postgres, 0.3 when empty
+type FuzzyConfig struct {
+	Threshold float64 `json:"threshold"`
+}
+
+// FilterConfig maps a filter to its internal column or field, the filter name is used when empty,
+// and sets the type of its values (int, float, bool, date, time, uuid or enum with the allowed values)
+type FilterConfig struct {
+	Column string   `json:"column"`
+	Type   string   `json:"type"`
+	Values []string `json:"values"`
+}
+
 // NewConfig ...
 func NewConfig() (*AppConfig, manager.IConfig, error) {
 	appConfig := &AppConfig{}
@@ -4,4 +4,34 @@ const (
 	constPage   = "page"
 	constSize   = "size"
 	constSearch = "search"
+	constSort   = "sort"
+	constCursor = "cursor"
+
+	constValueSeparator = ","
+	constSortDesc       = "-"
+	constSortAsc        = "+"
+
+	constBackendDatabase = "database"
+	constBackendElastic  = "elastic"
+
+	constTagDatabase = "db"
+	constTagElastic  = "json"
+	constTagSearch   = "search"
+
+	constOptionFilter = "filter"
+	constOptionSearch = "search"
+	constOptionSort   = "sort"
+	constOptionBoost  = "boost"
+	constOptionMatch  = "match"
+
+	constOptionValueSeparator = "="
+
+	// constRelevance is the sortable of the full text search rank, on the internal column
+	// constRelevanceColumn that can't be confused with a valid identifier
+	constRelevance       = "relevance"
+	constRelevanceColumn = "@relevance"
+	constElasticScore    = "_score"
+
+	constElasticSearch = "_search"
+	constElasticCount  = "_count"
 )
@@ -0,0 +1,27 @@
+package search
+
+import "context"
+
+// run executes the function, returning as soon as the context is done. The backend clients
+// don't accept a context, so a cancelled call is abandoned instead of interrupted
+func run(ctx context.Context, function func() error) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	if ctx.Done() == nil {
+		return function()
+	}
+
+	done := make(chan error, 1)
+	go func() {
+		done <- function()
+	}()
+
+	select {
+	case err := <-done:
+		return err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,110 @@
+package search
+
+import (
+	"context"
+	goerrors "errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/joaosoft/elastic"
+)
+
+func TestRun(t *testing.T) {
+	errFunction := goerrors.New("function")
+
+	cancelled, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	deadline, cancelDeadline := context.WithTimeout(context.Background(), time.Minute)
+	defer cancelDeadline()
+
+	expired, cancelExpired := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancelExpired()
+
+	tests := []struct {
+		name     string
+		ctx      context.Context
+		delay    time.Duration
+		expected error
+		called   bool
+	}{
+		{name: "without deadline", ctx: context.Background(), expected: errFunction, called: true},
+		{name: "before the deadline", ctx: deadline, expected: errFunction, called: true},
+		{name: "cancelled before", ctx: cancelled, expected: context.Canceled, called: false},
+		{name: "abandoned on the deadline", ctx: expired, delay: 100 * time.Millisecond, expected: context.DeadlineExceeded, called: true},
+	}
+
+	for _, test := range tests {
+		called := make(chan bool, 1)
+		err := run(test.ctx, func() error {
+			called <- true
+			time.Sleep(test.delay)
+			return errFunction
+		})
+
+		if !goerrors.Is(err, test.expected) {
+			t.Errorf("%s: %v, expected %v", test.name, err, test.expected)
+		}
+
+		select {
+		case <-called:
+			if !test.called {
+				t.Errorf("%s: the function was called", test.name)
+			}
+		default:
+			if test.called {
+				t.Errorf("%s: the function wasn't called", test.name)
+			}
+		}
+	}
+}
+
+// TestTimeout checks that a slow backend is interrupted with a timeout error, by the handler or by the search timeout
+func TestTimeout(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-r.Context().Done():
+		case <-time.After(300 * time.Millisecond):
+		}
+	}))
+	defer server.Close()
+
+	client, err := elastic.NewElastic(elastic.WithConfiguration(&elastic.ElasticConfig{Endpoint: server.URL}))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	search := &Search{}
+	search.Reconfigure(WithTimeout(20 * time.Millisecond))
+
+	for name, handler := range map[string]*searchHandler{
+		"search timeout":  search.NewElasticSearch(client.Search().Index("person")),
+		"handler timeout": (&Search{}).NewElasticSearch(client.Search().Index("person")).Timeout(20 * time.Millisecond),
+	} {
+		start := time.Now()
+		_, errs := handler.Bind(&[]*testPerson{}).Exec()
+
+		var timeout *TimeoutError
+		if len(errs) != 1 || !goerrors.As(errs[0], &timeout) || timeout.Backend != constBackendElastic || timeout.Timeout != 20*time.Millisecond {
+			t.Errorf("%s: %v, expected a timeout error", name, errs)
+		}
+
+		if StatusCode(errs...) != http.StatusGatewayTimeout {
+			t.Errorf("%s: status %d", name, StatusCode(errs...))
+		}
+
+		if elapsed := time.Since(start); elapsed > 500*time.Millisecond {
+			t.Errorf("%s: returned after %s", name, elapsed)
+		}
+	}
+
+	// cancelled by the caller
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	if _, errs := (&Search{}).NewElasticSearch(client.Search().Index("person")).Bind(&[]*testPerson{}).ExecContext(ctx); len(errs) != 1 || !goerrors.Is(errs[0], context.Canceled) {
+		t.Errorf("cancelled: %v, expected %v", errs, context.Canceled)
+	}
+}
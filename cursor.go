@@ -0,0 +1,144 @@
+package search
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"reflect"
+	"strings"
+)
+
+type paginationMode int
+
+const (
+	paginationModeOffset paginationMode = iota
+	paginationModeCursor
+)
+
+type cursor struct {
+	Values   []interface{} `json:"v"`
+	Backward bool          `json:"b,omitempty"`
+}
+
+func (cursor *cursor) encode() string {
+	bytes, _ := json.Marshal(cursor)
+	return base64.RawURLEncoding.EncodeToString(bytes)
+}
+
+func decodeCursor(token string) (*cursor, error) {
+	data, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return nil, newInvalidCursorError(token)
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+
+	cursor := &cursor{}
+	if err = decoder.Decode(cursor); err != nil || len(cursor.Values) == 0 {
+		return nil, newInvalidCursorError(token)
+	}
+
+	// only the scalar values are encoded on the queries, the null ones are compared by the null ordering
+	for _, value := range cursor.Values {
+		switch value.(type) {
+		case nil, string, json.Number, bool:
+		default:
+			return nil, newInvalidCursorError(token)
+		}
+	}
+
+	return cursor, nil
+}
+
+// setCursors trims the extra row loaded to detect more results and builds the next and previous cursors
+// from the sort key values of the edge rows, given by their index in the loaded rows
+func (searchData *searchData) setCursors(values func(index int, row reflect.Value) ([]interface{}, bool)) {
+	value := reflect.ValueOf(searchData.object)
+	if value.Kind() != reflect.Ptr || value.Elem().Kind() != reflect.Slice {
+		return
+	}
+	rows := value.Elem()
+
+	hasMore := searchData.size > 0 && rows.Len() > searchData.size
+	if hasMore {
+		rows.Set(rows.Slice(0, searchData.size))
+	}
+
+	if rows.Len() == 0 {
+		return
+	}
+
+	// the rows are loaded in the reverse order when paginating backward
+	backward := searchData.cursor != nil && searchData.cursor.Backward
+	first, last := 0, rows.Len()-1
+	if backward {
+		first, last = last, first
+	}
+
+	if hasMore || backward {
+		if values, ok := values(last, rows.Index(last)); ok {
+			searchData.nextCursor = (&cursor{Values: values}).encode()
+		}
+	}
+
+	if (backward && hasMore) || (!backward && searchData.cursor != nil) {
+		if values, ok := values(first, rows.Index(first)); ok {
+			searchData.previousCursor = (&cursor{Values: values, Backward: true}).encode()
+		}
+	}
+
+	if backward {
+		swap := reflect.Swapper(rows.Interface())
+		for i, j := 0, rows.Len()-1; i < j; i, j = i+1, j-1 {
+			swap(i, j)
+		}
+	}
+}
+
+// rowValues reads the values of the order columns from a row
+func rowValues(row reflect.Value, orders orders, tag string) ([]interface{}, bool) {
+	for row.Kind() == reflect.Ptr || row.Kind() == reflect.Interface {
+		if row.IsNil() {
+			return nil, false
+		}
+		row = row.Elem()
+	}
+
+	values := make([]interface{}, 0, len(orders))
+	for _, order := range orders {
+		column := order.column
+		if index := strings.LastIndex(column, "."); index > -1 {
+			column = column[index+1:]
+		}
+
+		value, ok := fieldByTag(row, tag, column)
+		if !ok {
+			return nil, false
+		}
+		values = append(values, value)
+	}
+
+	return values, true
+}
+
+func fieldByTag(row reflect.Value, tag string, name string) (interface{}, bool) {
+	switch row.Kind() {
+	case reflect.Map:
+		value := row.MapIndex(reflect.ValueOf(name))
+		if !value.IsValid() {
+			return nil, false
+		}
+		return value.Interface(), true
+	case reflect.Struct:
+		for i := 0; i < row.NumField(); i++ {
+			field := row.Type().Field(i)
+			fieldName := strings.Split(field.Tag.Get(tag), ",")[0]
+			if fieldName == name || (fieldName == "" && strings.EqualFold(field.Name, name)) {
+				return row.Field(i).Interface(), true
+			}
+		}
+	}
+
+	return nil, false
+}
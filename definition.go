@@ -0,0 +1,319 @@
+package search
+
+import (
+	"fmt"
+	"reflect"
+	"sync/atomic"
+	"time"
+
+	"github.com/joaosoft/dbr"
+	"github.com/joaosoft/elastic"
+)
+
+// SearchDefinition is a search configured once, usually at startup, and reused by concurrent
+// requests, each one executed on its own handler with a new statement and bound objects.
+// It's compiled by Compile or by the first Handler, being read only after that, so the concurrent
+// handlers don't race with changes to it. The statements are created by a function instead of cloning
+// a base statement, since the dbr statements and elastic search services can't be cloned
+// (they don't have a clone and keep their conditions and queries on unexported slices and maps)
+type SearchDefinition struct {
+	compiled       atomic.Bool
+	search         *Search
+	backend        string
+	newClient      func() searchClient
+	hasPagination  bool
+	paginationMode paginationMode
+	tiebreaker     string
+	hasMetadata    bool
+	filters        map[string]string
+	filterTypes    map[string]*ValueType
+	strictFilters  bool
+	searchFilters  []string
+	searchFields   searchFields
+	fullText       *fullText
+	fuzzy          *fuzzy
+	sortables      map[string]string
+	metadata       map[string]*definitionMetadata
+	orders         orders
+	size           int
+	maxSize        int
+	timeout        time.Duration
+	breaker        string
+	object         interface{}
+}
+
+type definitionMetadata struct {
+	newStmt  func() interface{}
+	function metadataFunction
+	object   interface{}
+}
+
+// NewDatabaseDefinition creates a definition on a database statement, the statement builder is called
+// for each request since the dbr statements are changed when executed and can't be shared
+func (search *Search) NewDatabaseDefinition(stmt func() *dbr.StmtSelect) *SearchDefinition {
+	return search.newSearchDefinition(func() searchClient {
+		return search.newDatabaseClient(stmt())
+	}, constBackendDatabase)
+}
+
+// NewElasticDefinition creates a definition on an elastic search service, the service builder is called
+// for each request since the search services are changed when executed and can't be shared
+func (search *Search) NewElasticDefinition(stmt func() *elastic.SearchService) *SearchDefinition {
+	return search.newSearchDefinition(func() searchClient {
+		return search.newElasticClient(stmt())
+	}, constBackendElastic)
+}
+
+func (search *Search) newSearchDefinition(newClient func() searchClient, backend string) *SearchDefinition {
+	return &SearchDefinition{
+		search:        search,
+		backend:       backend,
+		newClient:     newClient,
+		hasPagination: true,
+		hasMetadata:   true,
+		filters:       make(map[string]string),
+		filterTypes:   make(map[string]*ValueType),
+		searchFilters: make([]string, 0),
+		searchFields:  make(searchFields),
+		sortables:     make(map[string]string),
+		metadata:      make(map[string]*definitionMetadata),
+		maxSize:       search.maxSize,
+		timeout:       search.timeout,
+	}
+}
+
+func (definition *SearchDefinition) Filters(fields ...string) *SearchDefinition {
+	definition.mutable()
+	for _, field := range fields {
+		definition.filters[field] = field
+	}
+	return definition
+}
+
+func (definition *SearchDefinition) Filter(searchName string, internalName string) *SearchDefinition {
+	definition.mutable()
+	definition.filters[searchName] = internalName
+	return definition
+}
+
+func (definition *SearchDefinition) FilterType(searchName string, valueType *ValueType) *SearchDefinition {
+	definition.mutable()
+	definition.filterTypes[searchName] = valueType
+	return definition
+}
+
+func (definition *SearchDefinition) StrictFilters() *SearchDefinition {
+	definition.mutable()
+	definition.strictFilters = true
+	return definition
+}
+
+func (definition *SearchDefinition) SearchFilters(fields ...string) *SearchDefinition {
+	definition.mutable()
+	definition.searchFilters = append(definition.searchFilters, fields...)
+	return definition
+}
+
+func (definition *SearchDefinition) SearchField(field string, boost float64, match match) *SearchDefinition {
+	definition.mutable()
+	definition.searchFilters = append(definition.searchFilters, field)
+	definition.searchFields[field] = &searchField{boost: boost, match: match}
+	definition.sortables[constRelevance] = constRelevanceColumn
+	return definition
+}
+
+func (definition *SearchDefinition) FuzzySearch(threshold ...float64) *SearchDefinition {
+	definition.mutable()
+	definition.fuzzy = newFuzzy(threshold...)
+	definition.sortables[constRelevance] = constRelevanceColumn
+	return definition
+}
+
+func (definition *SearchDefinition) FullTextSearch(config string, vectorColumn ...string) *SearchDefinition {
+	definition.mutable()
+	definition.fullText = newFullText(config, vectorColumn...)
+	definition.sortables[constRelevance] = constRelevanceColumn
+	return definition
+}
+
+func (definition *SearchDefinition) Sortable(searchName string, internalName string) *SearchDefinition {
+	definition.mutable()
+	definition.sortables[searchName] = internalName
+	return definition
+}
+
+// FromModel registers the filters, search fields and sortables tagged on the model, as on the handler
+func (definition *SearchDefinition) FromModel(model interface{}) *SearchDefinition {
+	definition.mutable()
+	for _, field := range modelFields(model, tagOfBackend(definition.backend)) {
+		if field.filter {
+			definition.Filter(field.name, field.column)
+			if field.valueType != nil {
+				definition.FilterType(field.name, field.valueType)
+			}
+		}
+		if field.searchable {
+			if field.searchField != nil {
+				definition.SearchField(field.column, field.searchField.boost, field.searchField.match)
+			} else {
+				definition.SearchFilters(field.column)
+			}
+		}
+		if field.sortable {
+			definition.Sortable(field.name, field.column)
+		}
+	}
+	return definition
+}
+
+func (definition *SearchDefinition) WithoutPagination() *SearchDefinition {
+	definition.mutable()
+	definition.hasPagination = false
+	return definition
+}
+
+func (definition *SearchDefinition) WithCursorPagination() *SearchDefinition {
+	definition.mutable()
+	definition.paginationMode = paginationModeCursor
+	return definition
+}
+
+func (definition *SearchDefinition) Tiebreaker(field string) *SearchDefinition {
+	definition.mutable()
+	definition.tiebreaker = field
+	return definition
+}
+
+func (definition *SearchDefinition) WithoutMetadata() *SearchDefinition {
+	definition.mutable()
+	definition.hasMetadata = false
+	return definition
+}
+
+// Metadata adds a metadata statement, built for each request like the search statement,
+// loaded into a new object of the same type of the given one
+func (definition *SearchDefinition) Metadata(name string, stmt func() interface{}, object interface{}) *SearchDefinition {
+	definition.mutable()
+	if reflect.ValueOf(object).Kind() != reflect.Ptr {
+		panic(fmt.Sprintf("the object is not a pointer for the Metadata %s", name))
+	}
+	definition.metadata[name] = &definitionMetadata{newStmt: stmt, object: object}
+	return definition
+}
+
+func (definition *SearchDefinition) MetadataFunction(name string, function metadataFunction, object interface{}) *SearchDefinition {
+	definition.mutable()
+	definition.metadata[name] = &definitionMetadata{function: function, object: object}
+	return definition
+}
+
+func (definition *SearchDefinition) OrderBy(field string, direction direction) *SearchDefinition {
+	definition.mutable()
+	definition.orders = append(definition.orders, &order{column: field, direction: direction})
+	return definition
+}
+
+func (definition *SearchDefinition) Size(size int) *SearchDefinition {
+	definition.mutable()
+	definition.size = size
+	return definition
+}
+
+func (definition *SearchDefinition) MaxSize(maxSize int) *SearchDefinition {
+	definition.mutable()
+	definition.maxSize = maxSize
+	return definition
+}
+
+func (definition *SearchDefinition) Timeout(timeout time.Duration) *SearchDefinition {
+	definition.mutable()
+	definition.timeout = timeout
+	return definition
+}
+
+// Bind sets the type of the result, each request loads into a new object of the same type of the given one
+func (definition *SearchDefinition) Bind(object interface{}) *SearchDefinition {
+	definition.mutable()
+	definition.object = object
+	return definition
+}
+
+func (definition *SearchDefinition) CircuitBreaker(name string) *SearchDefinition {
+	definition.mutable()
+	definition.breaker = name
+	return definition
+}
+
+// Compile freezes the definition, panicking on the changes after it, to be shared by concurrent requests
+func (definition *SearchDefinition) Compile() *SearchDefinition {
+	definition.compiled.Store(true)
+	return definition
+}
+
+// mutable panics when the compiled definition is changed
+func (definition *SearchDefinition) mutable() {
+	if definition.compiled.Load() {
+		panic("the search definition is compiled and can't be changed")
+	}
+}
+
+// Handler creates the handler of a request, with a new statement and bound objects and
+// its own copy of the definition, so it can be changed without affecting other requests
+func (definition *SearchDefinition) Handler() *searchHandler {
+	definition.Compile()
+
+	handler := definition.search.newSearchHandler(definition.newClient())
+	handler.hasPagination = definition.hasPagination
+	handler.paginationMode = definition.paginationMode
+	handler.tiebreaker = definition.tiebreaker
+	handler.hasMetadata = definition.hasMetadata
+	handler.strictFilters = definition.strictFilters
+	handler.fullText = definition.fullText
+	handler.fuzzy = definition.fuzzy
+	handler.searchFilters = append(handler.searchFilters, definition.searchFilters...)
+	handler.orders = append(handler.orders, definition.orders...)
+	handler.size = definition.size
+	handler.maxSize = definition.maxSize
+	handler.timeout = definition.timeout
+	handler.object = newObject(definition.object)
+
+	if definition.breaker != "" {
+		handler.CircuitBreaker(definition.breaker)
+	}
+
+	for name, internalName := range definition.filters {
+		handler.filters[name] = internalName
+	}
+
+	for name, field := range definition.searchFields {
+		handler.searchFields[name] = field
+	}
+
+	for name, valueType := range definition.filterTypes {
+		handler.filterTypes[name] = valueType
+	}
+
+	for name, internalName := range definition.sortables {
+		handler.sortables[name] = internalName
+	}
+
+	for name, metadata := range definition.metadata {
+		item := &Metadata{function: metadata.function, object: newObject(metadata.object)}
+		if metadata.newStmt != nil {
+			item.stmt = metadata.newStmt()
+		}
+		handler.metadata[name] = item
+	}
+
+	return handler
+}
+
+// newObject creates a new object of the same type of the given pointer
+func newObject(object interface{}) interface{} {
+	value := reflect.ValueOf(object)
+	if value.Kind() != reflect.Ptr {
+		return object
+	}
+
+	return reflect.New(value.Type().Elem()).Interface()
+}
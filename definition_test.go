@@ -0,0 +1,90 @@
+package search
+
+import (
+	"fmt"
+	"reflect"
+	"sync"
+	"testing"
+
+	"github.com/joaosoft/dbr"
+)
+
+func TestDefinitionCompiled(t *testing.T) {
+	changes := map[string]func(definition *SearchDefinition){
+		"filters":        func(definition *SearchDefinition) { definition.Filters("age") },
+		"filter type":    func(definition *SearchDefinition) { definition.FilterType("age", TypeInt) },
+		"search filters": func(definition *SearchDefinition) { definition.SearchFilters("first_name") },
+		"sortable":       func(definition *SearchDefinition) { definition.Sortable("age", "age") },
+		"order by":       func(definition *SearchDefinition) { definition.OrderBy("age", orderAsc) },
+		"size":           func(definition *SearchDefinition) { definition.Size(10) },
+		"max size":       func(definition *SearchDefinition) { definition.MaxSize(10) },
+		"bind":           func(definition *SearchDefinition) { definition.Bind(&[]*testPerson{}) },
+	}
+
+	for name, change := range changes {
+		definition := (&Search{}).NewDatabaseDefinition(func() *dbr.StmtSelect { return nil })
+
+		// changed before being compiled
+		change(definition)
+		definition.Compile()
+
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("%s: the compiled definition was changed", name)
+				}
+			}()
+			change(definition)
+		}()
+	}
+}
+
+// TestDefinitionHandlers runs concurrent requests on a definition, each one with its own statement,
+// object and changes on the handler that don't affect the definition nor the other requests
+func TestDefinitionHandlers(t *testing.T) {
+	db := newPersons(t)
+
+	definition := (&Search{}).NewDatabaseDefinition(func() *dbr.StmtSelect { return db.Select("*").From("person") }).
+		Filters("first_name").
+		Sortable("id", "id_person").
+		Size(2).
+		Bind(&[]*testPerson{})
+
+	names := []string{"joao", "maria", "jose", "ana", "rui"}
+
+	var wg sync.WaitGroup
+	errs := make(chan error, len(names)*2)
+	for i := 0; i < 2; i++ {
+		for id, name := range names {
+			wg.Add(1)
+			go func(id int, name string) {
+				defer wg.Done()
+
+				// the age filter is only added on this handler
+				result, execErrs := definition.Handler().
+					Filter("age", "age").
+					Query(map[string]string{"first_name": name, "age[gte]": "0", constSort: "-id"}).
+					Exec()
+				if len(execErrs) > 0 {
+					errs <- fmt.Errorf("%s: %v", name, execErrs)
+					return
+				}
+
+				items := *result.Result.(*[]*testPerson)
+				if len(items) != 1 || items[0].IdPerson != id+1 || items[0].FirstName != name {
+					errs <- fmt.Errorf("%s: items %+v", name, items)
+				}
+			}(id, name)
+		}
+	}
+	wg.Wait()
+	close(errs)
+
+	for err := range errs {
+		t.Error(err)
+	}
+
+	if !reflect.DeepEqual(definition.filters, map[string]string{"first_name": "first_name"}) {
+		t.Errorf("the filters of the definition were changed: %v", definition.filters)
+	}
+}
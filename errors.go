@@ -0,0 +1,184 @@
+package search
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/joaosoft/errors"
+)
+
+const (
+	CodeInvalidParameter   = "invalid_parameter"
+	CodeUnknownFilter      = "unknown_filter"
+	CodeUnsupportedSort    = "unsupported_sort"
+	CodeInvalidCursor      = "invalid_cursor"
+	CodeCursorWithoutOrder = "cursor_without_order"
+	CodeUnknownSearch      = "unknown_search"
+	CodeInvalidIdentifier  = "invalid_identifier"
+	CodeBackendUnavailable = "backend_unavailable"
+	CodeBackendError       = "backend_error"
+	CodeTimeout            = "timeout"
+)
+
+var (
+	ErrorCursorWithoutOrder = errors.New(errors.LevelError, CodeCursorWithoutOrder, "cursor pagination requires at least one order")
+	ErrorCircuitOpen        = errors.New(errors.LevelError, CodeBackendUnavailable, "the circuit breaker of the backend is open")
+	ErrorFullTextDialect    = errors.New(errors.LevelError, 0, "the full text search is only supported on postgres")
+)
+
+// searchError holds the joaosoft error of the typed errors, coded with their kind
+type searchError struct {
+	err *errors.Error
+}
+
+func newSearchError(level errors.Level, code string, message string, params ...interface{}) searchError {
+	return searchError{err: errors.New(level, code, message, params...)}
+}
+
+func (e searchError) Error() string {
+	return e.err.Error()
+}
+
+// Code returns the code of the kind of the error
+func (e searchError) Code() string {
+	return fmt.Sprint(e.err.Code)
+}
+
+// InvalidParameterError is returned for each query parameter with a value that isn't valid for the type of its filter
+type InvalidParameterError struct {
+	searchError
+	Parameter string
+	Value     string
+	Reason    string
+}
+
+func newInvalidParameterError(parameter string, value string, reason string) *InvalidParameterError {
+	return &InvalidParameterError{
+		searchError: newSearchError(errors.LevelWarn, CodeInvalidParameter, "invalid parameter %s with value %s: %s", parameter, value, reason),
+		Parameter:   parameter,
+		Value:       value,
+		Reason:      reason,
+	}
+}
+
+// UnknownFilterError is returned on strict filters for each query parameter that isn't a filter
+type UnknownFilterError struct {
+	searchError
+	Parameter string
+}
+
+func newUnknownFilterError(parameter string) *UnknownFilterError {
+	return &UnknownFilterError{
+		searchError: newSearchError(errors.LevelWarn, CodeUnknownFilter, "unknown filter %s", parameter),
+		Parameter:   parameter,
+	}
+}
+
+// UnsupportedSortError is returned when the sort parameter references a field that isn't sortable
+type UnsupportedSortError struct {
+	searchError
+	Field string
+}
+
+func newUnsupportedSortError(field string) *UnsupportedSortError {
+	return &UnsupportedSortError{
+		searchError: newSearchError(errors.LevelWarn, CodeUnsupportedSort, "unsupported sort field %s", field),
+		Field:       field,
+	}
+}
+
+// InvalidCursorError is returned when the cursor parameter can't be decoded
+type InvalidCursorError struct {
+	searchError
+	Cursor string
+}
+
+func newInvalidCursorError(cursor string) *InvalidCursorError {
+	return &InvalidCursorError{
+		searchError: newSearchError(errors.LevelWarn, CodeInvalidCursor, "invalid cursor %s", cursor),
+		Cursor:      cursor,
+	}
+}
+
+// UnknownSearchError is returned when there isn't a search declared with the name
+type UnknownSearchError struct {
+	searchError
+	Name string
+}
+
+func newUnknownSearchError(name string) *UnknownSearchError {
+	return &UnknownSearchError{
+		searchError: newSearchError(errors.LevelWarn, CodeUnknownSearch, "unknown search %s", name),
+		Name:        name,
+	}
+}
+
+// InvalidIdentifierError is returned when a column of the filters, search fields or orders isn't a plain identifier
+type InvalidIdentifierError struct {
+	searchError
+	Identifier string
+}
+
+func newInvalidIdentifierError(identifier string) *InvalidIdentifierError {
+	return &InvalidIdentifierError{
+		searchError: newSearchError(errors.LevelError, CodeInvalidIdentifier, "invalid identifier %q", identifier),
+		Identifier:  identifier,
+	}
+}
+
+// BackendUnavailableError is returned when the backend can't be reached or its circuit breaker is open
+type BackendUnavailableError struct {
+	searchError
+	Backend string
+	Err     error
+}
+
+func newBackendUnavailableError(backend string, err error) *BackendUnavailableError {
+	return &BackendUnavailableError{
+		searchError: newSearchError(errors.LevelError, CodeBackendUnavailable, "the backend %s is unavailable: %s", backend, err),
+		Backend:     backend,
+		Err:         err,
+	}
+}
+
+func (e *BackendUnavailableError) Unwrap() error {
+	return e.Err
+}
+
+// BackendError is returned when the backend answers the search with an error
+type BackendError struct {
+	searchError
+	Backend string
+	Status  int
+	Reason  string
+}
+
+func newBackendError(backend string, status int, reason string) *BackendError {
+	return &BackendError{
+		searchError: newSearchError(errors.LevelError, CodeBackendError, "the backend %s answered with the status %d: %s", backend, status, reason),
+		Backend:     backend,
+		Status:      status,
+		Reason:      reason,
+	}
+}
+
+// TimeoutError is returned when the search doesn't finish before its deadline
+type TimeoutError struct {
+	searchError
+	Backend string
+	Timeout time.Duration
+	Err     error
+}
+
+func newTimeoutError(backend string, timeout time.Duration, err error) *TimeoutError {
+	return &TimeoutError{
+		searchError: newSearchError(errors.LevelError, CodeTimeout, "the search on the backend %s timed out: %s", backend, err),
+		Backend:     backend,
+		Timeout:     timeout,
+		Err:         err,
+	}
+}
+
+func (e *TimeoutError) Unwrap() error {
+	return e.Err
+}
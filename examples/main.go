@@ -12,10 +12,10 @@ import (
 )
 
 type Person struct {
-	IdPerson  int    `json:"id_person" db:"id_person"`
-	FirstName string `json:"first_name" db:"first_name"`
-	LastName  string `json:"last_name" db:"last_name"`
-	Age       int    `json:"age" db:"age"`
+	IdPerson  int    `json:"id_person" db:"id_person" search:"filter,sort"`
+	FirstName string `json:"first_name" db:"first_name" search:"filter,search,sort,boost=2,match=prefix"`
+	LastName  string `json:"last_name" db:"last_name" search:"filter,search,sort"`
+	Age       int    `json:"age" db:"age" search:"filter,sort"`
 	Active    bool   `json:"active" db:"active"`
 	IdAddress int    `json:"fk_address" db:"fk_address"`
 }
@@ -29,7 +29,7 @@ type Address struct {
 
 var db, _ = dbr.New()
 var el, _ = elastic.NewElastic()
-var searcher, _ = search.New()
+var searcher, _ = search.New(search.WithDatabase(db), search.WithElastic(el))
 
 func main() {
 	// with database
@@ -37,6 +37,9 @@ func main() {
 	FillDatatabase()
 	<-time.After(5 * time.Second)
 	SearchFromDatabase()
+	SearchTypedFromDatabase()
+	SearchFromDatabaseDefinition()
+	SearchNamed()
 	CleanDatabase()
 
 	// with elastic
@@ -99,6 +102,73 @@ func SearchFromDatabase() {
 	}
 }
 
+func SearchTypedFromDatabase() {
+	result, err := search.Database[Person](searcher,
+		db.Select("*").
+			From("search.person")).
+		Query(map[string]string{"age[gte]": "5", "sort": "-age"}).
+		Path("http://teste.pt").
+		Page(1).
+		Size(3).
+		Exec()
+	if err != nil {
+		panic(err)
+	}
+
+	for _, person := range result.Items {
+		fmt.Printf("\n\nPerson: %d %s %s", person.IdPerson, person.FirstName, person.LastName)
+	}
+}
+
+var personsDefinition = searcher.NewDatabaseDefinition(func() *dbr.StmtSelect {
+	return db.Select("*").
+		From("search.person")
+}).
+	FromModel(Person{}).
+	FuzzySearch().
+	OrderBy("id_person", "asc").
+	Bind(&[]Person{}).
+	Size(3).
+	MaxSize(10)
+
+func SearchFromDatabaseDefinition() {
+	result, err := personsDefinition.Handler().
+		Query(map[string]string{"age[gte]": "5", "sort": "-age"}).
+		Path("http://teste.pt").
+		Exec()
+
+	if err != nil {
+		panic(err)
+	}
+
+	if result != nil {
+		b, _ := json.MarshalIndent(result, "", "\t")
+		fmt.Printf("\n\nSearch: %s", string(b))
+	}
+}
+
+func SearchNamed() {
+	handler, err := searcher.Named("persons")
+	if err != nil {
+		panic(err)
+	}
+
+	result, errs := handler.
+		Query(map[string]string{"first_name": "joao", "sort": "-age"}).
+		Bind(&[]Person{}).
+		Path("http://teste.pt").
+		Exec()
+
+	if errs != nil {
+		panic(errs)
+	}
+
+	if result != nil {
+		b, _ := json.MarshalIndent(result, "", "\t")
+		fmt.Printf("\n\nSearch: %s", string(b))
+	}
+}
+
 func SearchFromElastic() {
 
 	result, err := searcher.NewElasticSearch(el.Search().
@@ -0,0 +1,87 @@
+package search
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	goerrors "errors"
+	"io"
+	"net"
+	"syscall"
+)
+
+type fallback interface {
+	Exec() (*searchResult, []error)
+}
+
+type contextFallback interface {
+	ExecContext(ctx context.Context) (*searchResult, []error)
+}
+
+// FallbackPolicy decides if the error of the previous backend should be handled by the fallback
+type FallbackPolicy func(err error) bool
+
+type fallbackItem struct {
+	fallback fallback
+	policy   FallbackPolicy
+}
+
+// FallbackOnAnyError falls back on every error
+func FallbackOnAnyError(err error) bool {
+	return true
+}
+
+// FallbackOnBackendError falls back on every error except the validation of the search parameters
+func FallbackOnBackendError(err error) bool {
+	return !isValidationError(err)
+}
+
+// FallbackOnUnavailable only falls back on timeouts and connection errors
+func FallbackOnUnavailable(err error) bool {
+	return isUnavailableError(err)
+}
+
+func (item *fallbackItem) exec(ctx context.Context) (*searchResult, []error) {
+	if fallback, ok := item.fallback.(contextFallback); ok {
+		return fallback.ExecContext(ctx)
+	}
+
+	return item.fallback.Exec()
+}
+
+func isValidationError(err error) bool {
+	var unsupportedSort *UnsupportedSortError
+	var invalidCursor *InvalidCursorError
+	var invalidParameter *InvalidParameterError
+	var unknownFilter *UnknownFilterError
+
+	return goerrors.As(err, &unsupportedSort) ||
+		goerrors.As(err, &invalidCursor) ||
+		goerrors.As(err, &invalidParameter) ||
+		goerrors.As(err, &unknownFilter) ||
+		goerrors.Is(err, ErrorCursorWithoutOrder)
+}
+
+func isUnavailableError(err error) bool {
+	var backendUnavailable *BackendUnavailableError
+	var timeout *TimeoutError
+	if goerrors.As(err, &backendUnavailable) || goerrors.As(err, &timeout) {
+		return true
+	}
+
+	if goerrors.Is(err, ErrorCircuitOpen) ||
+		goerrors.Is(err, context.DeadlineExceeded) ||
+		goerrors.Is(err, driver.ErrBadConn) ||
+		goerrors.Is(err, sql.ErrConnDone) ||
+		goerrors.Is(err, io.EOF) ||
+		goerrors.Is(err, io.ErrUnexpectedEOF) ||
+		goerrors.Is(err, syscall.ECONNREFUSED) ||
+		goerrors.Is(err, syscall.ECONNRESET) ||
+		goerrors.Is(err, syscall.EPIPE) {
+		return true
+	}
+
+	// the connection, dns and timeout errors
+	var netErr net.Error
+	return goerrors.As(err, &netErr)
+}
@@ -0,0 +1,136 @@
+package search
+
+import (
+	"strconv"
+	"strings"
+)
+
+type operator string
+
+const (
+	operatorEqual          operator = "eq"
+	operatorNotEqual       operator = "ne"
+	operatorGreater        operator = "gt"
+	operatorGreaterOrEqual operator = "gte"
+	operatorLess           operator = "lt"
+	operatorLessOrEqual    operator = "lte"
+	operatorIn             operator = "in"
+	operatorBetween        operator = "between"
+	operatorLike           operator = "like"
+	operatorIsNull         operator = "isnull"
+
+	// operatorOr groups the conditions of a repeated query parameter
+	operatorOr operator = "or"
+)
+
+var operators = map[operator]bool{
+	operatorEqual:          true,
+	operatorNotEqual:       true,
+	operatorGreater:        true,
+	operatorGreaterOrEqual: true,
+	operatorLess:           true,
+	operatorLessOrEqual:    true,
+	operatorIn:             true,
+	operatorBetween:        true,
+	operatorLike:           true,
+	operatorIsNull:         true,
+}
+
+type condition struct {
+	column   string
+	operator operator
+	values   []string
+	parsed   []interface{}
+	children conditions
+}
+
+type conditions []*condition
+
+// parseFilterKey splits a query key like "age[gte]" into the filter name and the operator
+func parseFilterKey(key string) (string, operator, bool) {
+	start := strings.Index(key, "[")
+	if start == -1 {
+		return key, operatorEqual, true
+	}
+
+	if !strings.HasSuffix(key, "]") || start == 0 {
+		return "", "", false
+	}
+
+	op := operator(strings.ToLower(key[start+1 : len(key)-1]))
+	if !operators[op] {
+		return "", "", false
+	}
+
+	return key[:start], op, true
+}
+
+// newCondition builds the condition of a query parameter, where repeated values are joined with an OR
+func newCondition(column string, op operator, values []string) (*condition, bool) {
+	switch op {
+	case operatorEqual, operatorIn:
+		items := make([]string, 0, len(values))
+		for _, value := range values {
+			if op == operatorIn {
+				items = append(items, strings.Split(value, constValueSeparator)...)
+			} else {
+				items = append(items, value)
+			}
+		}
+
+		if len(items) == 1 {
+			return &condition{column: column, operator: operatorEqual, values: items}, true
+		}
+		return &condition{column: column, operator: operatorIn, values: items}, true
+	}
+
+	children := make(conditions, 0, len(values))
+	for _, value := range values {
+		child, ok := newSingleCondition(column, op, value)
+		if !ok {
+			return nil, false
+		}
+		children = append(children, child)
+	}
+
+	if len(children) == 1 {
+		return children[0], true
+	}
+
+	return &condition{column: column, operator: operatorOr, children: children}, true
+}
+
+func newSingleCondition(column string, op operator, value string) (*condition, bool) {
+	values := []string{value}
+
+	switch op {
+	case operatorBetween:
+		values = strings.Split(value, constValueSeparator)
+		if len(values) != 2 {
+			return nil, false
+		}
+	case operatorIsNull:
+		if _, err := strconv.ParseBool(value); err != nil {
+			return nil, false
+		}
+	}
+
+	return &condition{column: column, operator: op, values: values}, true
+}
+
+func (condition *condition) isNull() bool {
+	isNull, _ := strconv.ParseBool(condition.values[0])
+	return isNull
+}
+
+func (condition *condition) interfaces() []interface{} {
+	if condition.parsed != nil {
+		return condition.parsed
+	}
+
+	values := make([]interface{}, len(condition.values))
+	for i, value := range condition.values {
+		values[i] = value
+	}
+	return values
+}
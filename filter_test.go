@@ -0,0 +1,58 @@
+package search
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseFilterKey(t *testing.T) {
+	tests := []struct {
+		key      string
+		name     string
+		operator operator
+		valid    bool
+	}{
+		{key: "age", name: "age", operator: operatorEqual, valid: true},
+		{key: "age[gte]", name: "age", operator: operatorGreaterOrEqual, valid: true},
+		{key: "age[IN]", name: "age", operator: operatorIn, valid: true},
+		{key: "age[or]", valid: false},
+		{key: "age[gte", valid: false},
+		{key: "[gte]", valid: false},
+		{key: "age[unknown]", valid: false},
+	}
+
+	for _, test := range tests {
+		name, operator, valid := parseFilterKey(test.key)
+		if valid != test.valid || (valid && (name != test.name || operator != test.operator)) {
+			t.Errorf("key %s: %s %s %t, expected %s %s %t", test.key, name, operator, valid, test.name, test.operator, test.valid)
+		}
+	}
+}
+
+// TestNewCondition checks that the repeated values of a query parameter are joined with an OR
+func TestNewCondition(t *testing.T) {
+	tests := []struct {
+		operator operator
+		values   []string
+		expected *condition
+	}{
+		{operator: operatorEqual, values: []string{"open"}, expected: &condition{column: "status", operator: operatorEqual, values: []string{"open"}}},
+		{operator: operatorEqual, values: []string{"open", "closed"}, expected: &condition{column: "status", operator: operatorIn, values: []string{"open", "closed"}}},
+		{operator: operatorIn, values: []string{"open,closed", "new"}, expected: &condition{column: "status", operator: operatorIn, values: []string{"open", "closed", "new"}}},
+		{operator: operatorIn, values: []string{"open"}, expected: &condition{column: "status", operator: operatorEqual, values: []string{"open"}}},
+		{operator: operatorLike, values: []string{"op", "cl"}, expected: &condition{column: "status", operator: operatorOr, children: conditions{
+			{column: "status", operator: operatorLike, values: []string{"op"}},
+			{column: "status", operator: operatorLike, values: []string{"cl"}},
+		}}},
+		{operator: operatorBetween, values: []string{"a,b"}, expected: &condition{column: "status", operator: operatorBetween, values: []string{"a", "b"}}},
+		{operator: operatorBetween, values: []string{"a,b", "c"}, expected: nil},
+		{operator: operatorIsNull, values: []string{"maybe"}, expected: nil},
+	}
+
+	for _, test := range tests {
+		condition, ok := newCondition("status", test.operator, test.values)
+		if ok != (test.expected != nil) || (ok && !reflect.DeepEqual(condition, test.expected)) {
+			t.Errorf("%s %v: %+v, expected %+v", test.operator, test.values, condition, test.expected)
+		}
+	}
+}
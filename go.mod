@@ -3,18 +3,20 @@ module github.com/joaosoft/search
 go 1.20
 
 require (
+	github.com/go-sql-driver/mysql v1.7.1
 	github.com/joaosoft/dbr v0.0.0-20230531144058-c4baa903d6ef
 	github.com/joaosoft/elastic v0.0.0-20230531144305-c599e1743cfc
 	github.com/joaosoft/errors v0.0.0-20230531141818-ebb38600b462
 	github.com/joaosoft/logger v0.0.0-20230531142923-753c0a3e836a
 	github.com/joaosoft/manager v0.0.0-20230531145924-a549066d2284
 	github.com/joaosoft/migration v0.0.0-20230531143955-8d9130f5a39d
+	github.com/joaosoft/web v0.0.0-20230531143830-cd31d8a8c35e
+	github.com/mattn/go-sqlite3 v1.14.33
 )
 
 require (
 	github.com/alphazero/Go-Redis v0.0.0-20120924171622-a0637b154364 // indirect
 	github.com/fsnotify/fsnotify v1.6.0 // indirect
-	github.com/go-sql-driver/mysql v1.7.1 // indirect
 	github.com/golang/snappy v0.0.1 // indirect
 	github.com/hashicorp/hcl v1.0.0 // indirect
 	github.com/joaosoft/auth-types/basic v0.0.0-20230531143726-6905d84fa794 // indirect
@@ -22,7 +24,6 @@ require (
 	github.com/joaosoft/color v0.0.0-20230531140514-b61c18d53e39 // indirect
 	github.com/joaosoft/json v0.0.0-20230531142934-29fc4385bd51 // indirect
 	github.com/joaosoft/validator v0.0.0-20230531142908-28a5b2f72266 // indirect
-	github.com/joaosoft/web v0.0.0-20230531143830-cd31d8a8c35e // indirect
 	github.com/joaosoft/writers v0.0.0-20230531142123-83465954fcda // indirect
 	github.com/labstack/echo v3.3.10+incompatible // indirect
 	github.com/labstack/gommon v0.4.0 // indirect
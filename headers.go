@@ -0,0 +1,95 @@
+package search
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/joaosoft/web"
+)
+
+const (
+	constHeaderLink       = "Link"
+	constHeaderTotalCount = "X-Total-Count"
+)
+
+type headerOptions struct {
+	withoutPaginationBody bool
+}
+
+// HeaderOption ...
+type HeaderOption func(options *headerOptions)
+
+// WithoutPaginationBody removes the pagination from the result body after writing the headers
+func WithoutPaginationBody() HeaderOption {
+	return func(options *headerOptions) {
+		options.withoutPaginationBody = true
+	}
+}
+
+// Headers returns the pagination as RFC 8288 Link and X-Total-Count headers
+func (result *searchResult) Headers() http.Header {
+	headers := make(http.Header)
+	if result.Pagination == nil {
+		return headers
+	}
+
+	links := make([]string, 0)
+	for _, link := range []struct {
+		rel string
+		url *string
+	}{
+		{rel: "first", url: result.Pagination.First},
+		{rel: "prev", url: result.Pagination.Previous},
+		{rel: "next", url: result.Pagination.Next},
+		{rel: "last", url: result.Pagination.Last},
+	} {
+		if link.url != nil {
+			links = append(links, fmt.Sprintf(`<%s>; rel="%s"`, *link.url, link.rel))
+		}
+	}
+
+	if len(links) > 0 {
+		headers.Set(constHeaderLink, strings.Join(links, ", "))
+	}
+
+	if result.Pagination.Total != nil {
+		headers.Set(constHeaderTotalCount, strconv.Itoa(*result.Pagination.Total))
+	}
+
+	return headers
+}
+
+// WriteHeaders writes the pagination headers on the response writer
+func (result *searchResult) WriteHeaders(writer http.ResponseWriter, options ...HeaderOption) {
+	for key, values := range result.Headers() {
+		writer.Header()[key] = values
+	}
+
+	result.applyHeaderOptions(options...)
+}
+
+// WriteContextHeaders writes the pagination headers on the response of the web context
+func (result *searchResult) WriteContextHeaders(ctx *web.Context, options ...HeaderOption) {
+	if ctx.Response.Headers == nil {
+		ctx.Response.Headers = make(web.Headers)
+	}
+
+	for key, values := range result.Headers() {
+		ctx.Response.Headers[key] = values
+	}
+
+	result.applyHeaderOptions(options...)
+}
+
+func (result *searchResult) applyHeaderOptions(options ...HeaderOption) {
+	headerOptions := &headerOptions{}
+	for _, option := range options {
+		option(headerOptions)
+	}
+
+	if headerOptions.withoutPaginationBody {
+		result.Pagination = nil
+	}
+}
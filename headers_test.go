@@ -0,0 +1,72 @@
+package search
+
+import (
+	"net/http/httptest"
+	"testing"
+
+	"github.com/joaosoft/web"
+)
+
+func TestHeaders(t *testing.T) {
+	first, previous, next, last := "/persons?page=1", "/persons?page=1", "/persons?page=3", "/persons?page=5"
+	total := 10
+
+	tests := []struct {
+		name       string
+		pagination *pagination
+		link       string
+		totalCount string
+	}{
+		{name: "without pagination", pagination: nil},
+		{
+			name:       "middle page",
+			pagination: &pagination{First: &first, Previous: &previous, Next: &next, Last: &last, Total: &total},
+			link:       `</persons?page=1>; rel="first", </persons?page=1>; rel="prev", </persons?page=3>; rel="next", </persons?page=5>; rel="last"`,
+			totalCount: "10",
+		},
+		{
+			name:       "cursor without total",
+			pagination: &pagination{Next: &next},
+			link:       `</persons?page=3>; rel="next"`,
+		},
+	}
+
+	for _, test := range tests {
+		headers := (&searchResult{Pagination: test.pagination}).Headers()
+		if link := headers.Get(constHeaderLink); link != test.link {
+			t.Errorf("%s: link %s, expected %s", test.name, link, test.link)
+		}
+
+		if totalCount := headers.Get(constHeaderTotalCount); totalCount != test.totalCount {
+			t.Errorf("%s: total count %s, expected %s", test.name, totalCount, test.totalCount)
+		}
+	}
+}
+
+func TestWriteHeaders(t *testing.T) {
+	next := "/persons?page=2"
+	total := 3
+
+	result := &searchResult{Pagination: &pagination{Next: &next, Total: &total}}
+	recorder := httptest.NewRecorder()
+	result.WriteHeaders(recorder)
+
+	if link := recorder.Header().Get(constHeaderLink); link != `</persons?page=2>; rel="next"` || recorder.Header().Get(constHeaderTotalCount) != "3" {
+		t.Errorf("headers %v", recorder.Header())
+	}
+
+	if result.Pagination == nil {
+		t.Error("the pagination was removed from the body")
+	}
+
+	ctx := &web.Context{Response: &web.Response{}}
+	result.WriteContextHeaders(ctx, WithoutPaginationBody())
+
+	if link := ctx.Response.Headers[constHeaderLink]; len(link) != 1 || link[0] != `</persons?page=2>; rel="next"` {
+		t.Errorf("context headers %v", ctx.Response.Headers)
+	}
+
+	if result.Pagination != nil {
+		t.Error("the pagination wasn't removed from the body")
+	}
+}
@@ -0,0 +1,131 @@
+package search
+
+import (
+	"context"
+	"reflect"
+	"time"
+)
+
+type hedgeResponse struct {
+	result *searchResult
+	errs   []error
+	copy   func()
+}
+
+// commit copies the object loaded by the winner to the bound object
+func (response *hedgeResponse) commit() *searchResult {
+	if response.copy != nil {
+		response.copy()
+	}
+	return response.result
+}
+
+// Hedge fires the first fallback when the backend hasn't answered after the delay,
+// taking the first successful result and cancelling the other search. Each search loads its own
+// object, copied to the bound one by the winner only; the cancelled search is abandoned and not
+// interrupted on the database, keeping its connection until the query finishes
+func (searchHandler *searchHandler) Hedge(delay time.Duration) *searchHandler {
+	searchHandler.hedgeDelay = delay
+	return searchHandler
+}
+
+func (searchHandler *searchHandler) execHedged(ctx context.Context, searchData *searchData) (*searchResult, []error) {
+	primaryCtx, cancelPrimary := context.WithCancel(ctx)
+	defer cancelPrimary()
+
+	hedgeCtx, cancelHedge := context.WithCancel(ctx)
+	defer cancelHedge()
+
+	// the primary search loads its own object, the slower search must not write on the result
+	primaryData := *searchData
+	primaryData.object = newObject(searchHandler.object)
+
+	primary := make(chan *hedgeResponse, 1)
+	go func() {
+		total, err := searchHandler.execClient(primaryCtx, &primaryData)
+		if err != nil {
+			primary <- &hedgeResponse{errs: []error{err}}
+			return
+		}
+		primary <- &hedgeResponse{
+			result: searchHandler.newResult(&primaryData, total),
+			copy:   func() { copyObject(searchHandler.object, primaryData.object) },
+		}
+	}()
+
+	timer := time.NewTimer(searchHandler.hedgeDelay)
+	defer timer.Stop()
+
+	select {
+	case response := <-primary:
+		if len(response.errs) == 0 {
+			return response.commit(), nil
+		}
+		return searchHandler.execFallbacks(ctx, response.errs[0], searchHandler.fallbacks)
+	case <-timer.C:
+	}
+
+	hedge := make(chan *hedgeResponse, 1)
+	go func() {
+		hedge <- execHedge(hedgeCtx, searchHandler.fallbacks[0])
+	}()
+
+	// take the first successful answer
+	errs := make([]error, 0)
+	for pending := 2; pending > 0; pending-- {
+		select {
+		case response := <-primary:
+			if len(response.errs) == 0 {
+				return response.commit(), nil
+			}
+			errs = append(errs, response.errs...)
+			primary = nil
+		case response := <-hedge:
+			if len(response.errs) == 0 {
+				return response.commit(), nil
+			}
+			errs = append(errs, response.errs...)
+			hedge = nil
+		}
+	}
+
+	result, errFallbacks := searchHandler.execFallbacks(ctx, errs[len(errs)-1], searchHandler.fallbacks[1:])
+	if len(errFallbacks) == 0 {
+		return result, nil
+	}
+
+	return nil, append(errs, errFallbacks[1:]...)
+}
+
+// execHedge runs the hedged fallback, on a copy of the search handlers loading their own object
+func execHedge(ctx context.Context, item *fallbackItem) *hedgeResponse {
+	handler, ok := item.fallback.(*searchHandler)
+	if !ok {
+		result, errs := item.exec(ctx)
+		return &hedgeResponse{result: result, errs: errs}
+	}
+
+	hedgeHandler := *handler
+	hedgeHandler.object = newObject(handler.object)
+
+	result, errs := hedgeHandler.ExecContext(ctx)
+	if len(errs) > 0 {
+		return &hedgeResponse{errs: errs}
+	}
+
+	result.Result = handler.object
+	return &hedgeResponse{
+		result: result,
+		copy:   func() { copyObject(handler.object, hedgeHandler.object) },
+	}
+}
+
+// copyObject copies the value loaded on the source pointer to the destination pointer
+func copyObject(destination interface{}, source interface{}) {
+	destinationValue, sourceValue := reflect.ValueOf(destination), reflect.ValueOf(source)
+	if destinationValue.Kind() != reflect.Ptr || destinationValue.IsNil() || destinationValue.Type() != sourceValue.Type() || destinationValue.Pointer() == sourceValue.Pointer() {
+		return
+	}
+
+	destinationValue.Elem().Set(sourceValue.Elem())
+}
@@ -0,0 +1,69 @@
+package search
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// slowClient loads the persons after the delay, ignoring the cancellation as an abandoned query
+type slowClient struct {
+	delay   time.Duration
+	persons []*testPerson
+}
+
+func (client *slowClient) Exec(ctx context.Context, searchData *searchData) (int, error) {
+	time.Sleep(client.delay)
+	reflect.ValueOf(searchData.object).Elem().Set(reflect.ValueOf(client.persons))
+	return len(client.persons), nil
+}
+
+func (client *slowClient) backend() string {
+	return constBackendDatabase
+}
+
+// TestHedge checks that the bound object only gets the rows of the winner, even when the slower search finishes later
+func TestHedge(t *testing.T) {
+	tests := []struct {
+		name     string
+		delay    time.Duration
+		backend  string
+		expected []string
+	}{
+		{name: "primary before the hedge", delay: 0, backend: "primary", expected: []string{"primary"}},
+		{name: "hedge winning", delay: 200 * time.Millisecond, backend: "replica", expected: []string{"joao", "maria"}},
+	}
+
+	db := newPersons(t)
+	for _, test := range tests {
+		search := &Search{}
+		items := make([]*testPerson, 0)
+
+		result, errs := search.newSearchHandler(&slowClient{delay: test.delay, persons: []*testPerson{{FirstName: "primary"}}}).
+			CircuitBreaker("primary").
+			Hedge(20 * time.Millisecond).
+			Fallback(search.NewDatabaseSearch(db.Select("*").From("person")).CircuitBreaker("replica").Size(2).Bind(&items)).
+			Bind(&items).
+			Exec()
+		if len(errs) > 0 {
+			t.Fatalf("%s: %v", test.name, errs)
+		}
+
+		if result.Backend != test.backend {
+			t.Errorf("%s: backend %s, expected %s", test.name, result.Backend, test.backend)
+		}
+
+		// the slower search finishing
+		time.Sleep(test.delay + 50*time.Millisecond)
+
+		names := make([]string, len(items))
+		for i, item := range items {
+			names[i] = item.FirstName
+		}
+
+		if !reflect.DeepEqual(names, test.expected) {
+			t.Errorf("%s: items %v, expected %v", test.name, names, test.expected)
+		}
+	}
+}
@@ -0,0 +1,150 @@
+package search
+
+import (
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// modelField is a field of a model with a search tag, named by its json tag and
+// mapped to the column or field of the backend
+type modelField struct {
+	name        string
+	column      string
+	filter      bool
+	searchable  bool
+	sortable    bool
+	valueType   *ValueType
+	searchField *searchField
+}
+
+// modelFields reads the fields with a search tag like `search:"filter,search,sort"` of a struct,
+// a pointer to a struct or a slice of them, mapping them with the given backend tag;
+// the search fields take the boost and the match strategy like `search:"search,boost=2,match=prefix"`
+func modelFields(model interface{}, tag string) []*modelField {
+	typ := reflect.TypeOf(model)
+	for typ != nil && (typ.Kind() == reflect.Ptr || typ.Kind() == reflect.Slice || typ.Kind() == reflect.Array) {
+		typ = typ.Elem()
+	}
+
+	if typ == nil || typ.Kind() != reflect.Struct {
+		return nil
+	}
+
+	return structFields(typ, tag)
+}
+
+func structFields(typ reflect.Type, tag string) []*modelField {
+	fields := make([]*modelField, 0)
+
+	for i := 0; i < typ.NumField(); i++ {
+		field := typ.Field(i)
+
+		if field.Anonymous {
+			embedded := field.Type
+			if embedded.Kind() == reflect.Ptr {
+				embedded = embedded.Elem()
+			}
+
+			if embedded.Kind() == reflect.Struct {
+				fields = append(fields, structFields(embedded, tag)...)
+				continue
+			}
+		}
+
+		options, ok := field.Tag.Lookup(constTagSearch)
+		if !ok || options == "-" {
+			continue
+		}
+
+		name := tagName(field, constTagElastic)
+		column := tagName(field, tag)
+		if name == "" || column == "" {
+			continue
+		}
+
+		item := &modelField{name: name, column: column, valueType: valueTypeOf(field.Type)}
+		for _, option := range strings.Split(options, constValueSeparator) {
+			option, value := strings.TrimSpace(option), ""
+			if index := strings.Index(option, constOptionValueSeparator); index > -1 {
+				option, value = option[:index], option[index+1:]
+			}
+
+			switch option {
+			case constOptionFilter:
+				item.filter = true
+			case constOptionSearch:
+				item.searchable = true
+			case constOptionSort:
+				item.sortable = true
+			case constOptionBoost:
+				if boost, err := strconv.ParseFloat(value, 64); err == nil {
+					item.field().boost = boost
+				}
+			case constOptionMatch:
+				if match, ok := matchByName(value); ok {
+					item.field().match = match
+				}
+			}
+		}
+
+		fields = append(fields, item)
+	}
+
+	return fields
+}
+
+// field returns the boost and match strategy of the search field, created with the defaults when not tagged yet
+func (field *modelField) field() *searchField {
+	if field.searchField == nil {
+		field.searchField = &searchField{boost: constDefaultBoost, match: matchContains}
+	}
+	return field.searchField
+}
+
+// tagName returns the name of the field on the tag, or the field name when it isn't tagged
+func tagName(field reflect.StructField, tag string) string {
+	name := strings.Split(field.Tag.Get(tag), ",")[0]
+	if name == "-" {
+		return ""
+	}
+
+	if name == "" {
+		return field.Name
+	}
+
+	return name
+}
+
+// tagOfBackend returns the tag with the column or field names of the backend
+func tagOfBackend(backend string) string {
+	if backend == constBackendDatabase {
+		return constTagDatabase
+	}
+	return constTagElastic
+}
+
+// valueTypeOf returns the value type of the filters on a field of the given type
+func valueTypeOf(typ reflect.Type) *ValueType {
+	if typ.Kind() == reflect.Ptr {
+		typ = typ.Elem()
+	}
+
+	if typ == reflect.TypeOf(time.Time{}) {
+		return TypeTime
+	}
+
+	switch typ.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return TypeInt
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return TypeUint
+	case reflect.Float32, reflect.Float64:
+		return TypeFloat
+	case reflect.Bool:
+		return TypeBool
+	}
+
+	return nil
+}
@@ -0,0 +1,76 @@
+package search
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+type testEmbedded struct {
+	CreatedAt time.Time `json:"created_at" db:"created_at" search:"filter,sort"`
+}
+
+type testModel struct {
+	testEmbedded
+	Id       uint     `json:"id" db:"id_model" search:"filter,sort"`
+	Name     string   `json:"name" db:"name" search:"filter,search,sort,boost=2,match=prefix"`
+	Surname  string   `json:"surname" search:"search"`
+	Age      *int8    `json:"age" db:"age" search:"filter"`
+	Score    float32  `json:"score" db:"score" search:"sort"`
+	Active   bool     `json:"active" db:"active" search:"filter"`
+	Tags     []string `json:"tags" db:"tags" search:"filter"`
+	Hidden   string   `json:"hidden" db:"-" search:"filter"`
+	Ignored  string   `json:"ignored" db:"ignored" search:"-"`
+	Untagged string   `json:"untagged" db:"untagged"`
+}
+
+func TestModelFields(t *testing.T) {
+	expected := []*modelField{
+		{name: "created_at", column: "created_at", filter: true, sortable: true, valueType: TypeTime},
+		{name: "id", column: "id_model", filter: true, sortable: true, valueType: TypeUint},
+		{name: "name", column: "name", filter: true, searchable: true, sortable: true, searchField: &searchField{boost: 2, match: matchPrefix}},
+		{name: "surname", column: "Surname", searchable: true},
+		{name: "age", column: "age", filter: true, valueType: TypeInt},
+		{name: "score", column: "score", sortable: true, valueType: TypeFloat},
+		{name: "active", column: "active", filter: true, valueType: TypeBool},
+		{name: "tags", column: "tags", filter: true},
+	}
+
+	for _, model := range []interface{}{testModel{}, &testModel{}, []*testModel{}, &[]testModel{}} {
+		fields := modelFields(model, constTagDatabase)
+		if !reflect.DeepEqual(fields, expected) {
+			t.Errorf("%T: fields", model)
+			for _, field := range fields {
+				t.Logf("%+v", field)
+			}
+		}
+	}
+
+	if fields := modelFields(10, constTagDatabase); fields != nil {
+		t.Errorf("fields of an int %v", fields)
+	}
+}
+
+func TestFromModel(t *testing.T) {
+	handler := (&Search{}).NewDatabaseSearch(newPersons(t).Select("*").From("person")).FromModel([]*testModel{})
+
+	if filters := map[string]string{"created_at": "created_at", "id": "id_model", "name": "name", "age": "age", "active": "active", "tags": "tags"}; !reflect.DeepEqual(handler.filters, filters) {
+		t.Errorf("filters %v, expected %v", handler.filters, filters)
+	}
+
+	if filterTypes := map[string]*ValueType{"created_at": TypeTime, "id": TypeUint, "age": TypeInt, "active": TypeBool}; !reflect.DeepEqual(handler.filterTypes, filterTypes) {
+		t.Errorf("filter types %v, expected %v", handler.filterTypes, filterTypes)
+	}
+
+	if searchFilters := []string{"name", "Surname"}; !reflect.DeepEqual(handler.searchFilters, searchFilters) {
+		t.Errorf("search filters %v, expected %v", handler.searchFilters, searchFilters)
+	}
+
+	if searchFields := (searchFields{"name": {boost: 2, match: matchPrefix}}); !reflect.DeepEqual(handler.searchFields, searchFields) {
+		t.Errorf("search fields %v, expected %v", handler.searchFields, searchFields)
+	}
+
+	if sortables := map[string]string{"created_at": "created_at", "id": "id_model", "name": "name", "score": "score", constRelevance: constRelevanceColumn}; !reflect.DeepEqual(handler.sortables, sortables) {
+		t.Errorf("sortables %v, expected %v", handler.sortables, sortables)
+	}
+}
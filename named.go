@@ -0,0 +1,142 @@
+package search
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/joaosoft/dbr"
+	"github.com/joaosoft/elastic"
+	"github.com/joaosoft/errors"
+)
+
+// newDefinitions compiles the searches declared on the configuration, the ones on a table
+// need the database connection and the ones on an index need the elastic client;
+// an invalid search fails instead of being registered without the invalid setting
+func (search *Search) newDefinitions(configs map[string]*DefinitionConfig) (map[string]*SearchDefinition, error) {
+	definitions := make(map[string]*SearchDefinition)
+
+	for name, config := range configs {
+		var definition *SearchDefinition
+
+		switch {
+		case config.Table != "":
+			if search.db == nil {
+				return nil, errors.New(errors.LevelError, 0, "the search %s requires a database connection", name)
+			}
+
+			table := config.Table
+			definition = search.NewDatabaseDefinition(func() *dbr.StmtSelect {
+				return search.db.Select("*").From(table)
+			})
+		case config.Index != "":
+			if search.elastic == nil {
+				return nil, errors.New(errors.LevelError, 0, "the search %s requires an elastic client", name)
+			}
+
+			index, indexType := config.Index, config.Type
+			definition = search.NewElasticDefinition(func() *elastic.SearchService {
+				stmt := search.elastic.Search().Index(index)
+				if indexType != "" {
+					stmt.Type(indexType)
+				}
+				return stmt
+			})
+		default:
+			return nil, errors.New(errors.LevelError, 0, "the search %s requires a table or an index", name)
+		}
+
+		for filter, filterConfig := range config.Filters {
+			column := filter
+			if filterConfig != nil && filterConfig.Column != "" {
+				column = filterConfig.Column
+			}
+			definition.Filter(filter, column)
+
+			if filterConfig != nil && filterConfig.Type != "" {
+				valueType, ok := valueTypeByName(filterConfig.Type, filterConfig.Values)
+				if !ok {
+					return nil, errors.New(errors.LevelError, 0, "invalid type %s of the filter %s on the search %s", filterConfig.Type, filter, name)
+				}
+				definition.FilterType(filter, valueType)
+			}
+		}
+
+		for sortable, column := range config.Sortables {
+			if column == "" {
+				column = sortable
+			}
+			definition.Sortable(sortable, column)
+		}
+
+		for _, order := range config.Order {
+			if strings.HasPrefix(order, constSortDesc) {
+				definition.OrderBy(strings.TrimPrefix(order, constSortDesc), orderDesc)
+			} else {
+				definition.OrderBy(strings.TrimPrefix(order, constSortAsc), orderAsc)
+			}
+		}
+
+		if config.Strict {
+			definition.StrictFilters()
+		}
+
+		definition.SearchFilters(config.Search...)
+
+		fields := make([]string, 0, len(config.Fields))
+		for field := range config.Fields {
+			fields = append(fields, field)
+		}
+		sort.Strings(fields)
+
+		for _, field := range fields {
+			boost, match := float64(constDefaultBoost), matchContains
+			if fieldConfig := config.Fields[field]; fieldConfig != nil {
+				var ok bool
+				if match, ok = matchByName(fieldConfig.Match); !ok {
+					return nil, errors.New(errors.LevelError, 0, "invalid match %s of the search field %s on the search %s", fieldConfig.Match, field, name)
+				}
+				if fieldConfig.Boost != 0 {
+					boost = fieldConfig.Boost
+				}
+			}
+			definition.SearchField(field, boost, match)
+		}
+
+		if config.Fuzzy != nil {
+			definition.FuzzySearch(config.Fuzzy.Threshold)
+		}
+
+		if config.FullText != nil {
+			definition.FullTextSearch(config.FullText.Config, config.FullText.Column)
+		}
+		definition.Size(config.Size)
+
+		if config.MaxSize > 0 {
+			definition.MaxSize(config.MaxSize)
+		}
+
+		if config.Breaker != "" {
+			definition.CircuitBreaker(config.Breaker)
+		}
+
+		definitions[name] = definition.Compile()
+	}
+
+	return definitions, nil
+}
+
+// Definition returns the search declared on the configuration with the name
+func (search *Search) Definition(name string) (*SearchDefinition, bool) {
+	definition, ok := search.definitions[name]
+	return definition, ok
+}
+
+// Named creates the handler of a request on the search declared on the configuration with the name
+func (search *Search) Named(name string) (*searchHandler, error) {
+	definition, ok := search.definitions[name]
+	if !ok {
+		return nil, newUnknownSearchError(name)
+	}
+
+	return definition.Handler(), nil
+}
@@ -0,0 +1,128 @@
+package search
+
+import (
+	"encoding/json"
+	goerrors "errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/joaosoft/elastic"
+)
+
+func TestNewDefinitionsErrors(t *testing.T) {
+	client, err := elastic.NewElastic(elastic.WithConfiguration(&elastic.ElasticConfig{Endpoint: "http://localhost:9200"}))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name   string
+		config string
+		err    string
+	}{
+		{name: "without table nor index", config: `{}`, err: "requires a table or an index"},
+		{name: "without database", config: `{"table": "person"}`, err: "requires a database connection"},
+		{name: "without elastic", config: `{"index": "person"}`, err: "requires an elastic client"},
+		{name: "invalid filter type", config: `{"table": "person", "filters": {"age": {"type": "integer"}}}`, err: "invalid type integer of the filter age"},
+		{name: "invalid match", config: `{"table": "person", "search_fields": {"first_name": {"match": "regex"}}}`, err: "invalid match regex of the search field first_name"},
+		{name: "valid on a table", config: `{"table": "person", "filters": {"age": {"type": "int"}}, "search_fields": {"first_name": {"match": "prefix"}}}`},
+		{name: "valid on an index", config: `{"index": "person", "type": "_doc"}`},
+	}
+
+	db := newPersons(t)
+	for _, test := range tests {
+		config := &DefinitionConfig{}
+		if err := json.Unmarshal([]byte(test.config), config); err != nil {
+			t.Fatal(err)
+		}
+
+		search := &Search{elastic: client}
+		if test.name != "without database" {
+			search.db = db
+		}
+		if test.name == "without elastic" {
+			search.elastic = nil
+		}
+
+		_, err := search.newDefinitions(map[string]*DefinitionConfig{"persons": config})
+		switch {
+		case test.err == "" && err != nil:
+			t.Errorf("%s: unexpected error %s", test.name, err)
+		case test.err != "" && (err == nil || !strings.Contains(err.Error(), test.err)):
+			t.Errorf("%s: error %v, expected %s", test.name, err, test.err)
+		}
+	}
+}
+
+// TestNamed builds the handlers of a search declared on the configuration
+func TestNamed(t *testing.T) {
+	configs := make(map[string]*DefinitionConfig)
+	if err := json.Unmarshal([]byte(`{
+		"persons": {
+			"table": "person",
+			"filters": {"name": {"column": "first_name"}, "age": {"type": "int"}},
+			"strict_filters": true,
+			"search_fields": {"last_name": {}},
+			"sortables": {"id": "id_person", "age": ""},
+			"order": ["-age", "+id_person"],
+			"size": 2,
+			"max_size": 3
+		}
+	}`), &configs); err != nil {
+		t.Fatal(err)
+	}
+
+	search := &Search{db: newPersons(t)}
+	definitions, err := search.newDefinitions(configs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	search.definitions = definitions
+
+	tests := []struct {
+		query    map[string]string
+		expected []int
+		errs     int
+	}{
+		{query: map[string]string{}, expected: []int{3, 4}},
+		{query: map[string]string{constSize: "10"}, expected: []int{3, 4, 1}},
+		{query: map[string]string{"name": "maria"}, expected: []int{2}},
+		{query: map[string]string{"age[lte]": "30", constSort: "id"}, expected: []int{1, 2}},
+		{query: map[string]string{constSearch: "s"}, expected: []int{3, 4}},
+		{query: map[string]string{"age": "old"}, errs: 1},
+		{query: map[string]string{"first_name": "joao"}, errs: 1},
+		{query: map[string]string{constSort: "first_name"}, errs: 1},
+	}
+
+	for _, test := range tests {
+		handler, err := search.Named("persons")
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		result, errs := handler.Query(test.query).Bind(&[]*testPerson{}).Exec()
+		if len(errs) != test.errs {
+			t.Errorf("%v: errors %v, expected %d", test.query, errs, test.errs)
+			continue
+		}
+
+		if test.errs > 0 {
+			continue
+		}
+
+		ids := make([]int, 0)
+		for _, item := range *result.Result.(*[]*testPerson) {
+			ids = append(ids, item.IdPerson)
+		}
+
+		if !reflect.DeepEqual(ids, test.expected) {
+			t.Errorf("%v: %v, expected %v", test.query, ids, test.expected)
+		}
+	}
+
+	var unknownSearch *UnknownSearchError
+	if _, err := search.Named("unknown"); !goerrors.As(err, &unknownSearch) {
+		t.Errorf("unknown search: %v", err)
+	}
+}
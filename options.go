@@ -1,6 +1,10 @@
 package search
 
 import (
+	"time"
+
+	"github.com/joaosoft/dbr"
+	"github.com/joaosoft/elastic"
 	logger "github.com/joaosoft/logger"
 	"github.com/joaosoft/manager"
 )
@@ -50,3 +54,50 @@ func WithMaxSize(maxSize int) SearchOption {
 		search.maxSize = maxSize
 	}
 }
+
+// WithTimeout ...
+func WithTimeout(timeout time.Duration) SearchOption {
+	return func(search *Search) {
+		search.timeout = timeout
+	}
+}
+
+// WithCircuitBreaker ...
+func WithCircuitBreaker(threshold int, cooldown time.Duration) SearchOption {
+	return func(search *Search) {
+		search.breakerThreshold = threshold
+		search.breakerCooldown = cooldown
+	}
+}
+
+// WithShadow samples the shadow searches with the rate (from 0 to 1, all of them when 0) and limits the ones
+// running at the same time (10 by default), skipping the others so a slow secondary backend doesn't pile them up
+func WithShadow(sampleRate float64, limit int) SearchOption {
+	return func(search *Search) {
+		search.shadowRate = sampleRate
+		search.shadowLimit = limit
+	}
+}
+
+// WithCollation sets the case insensitive collation of the searches on mysql (as utf8mb4_general_ci),
+// that must be valid for the charset of the columns, the values are compared with LOWER() when it isn't set;
+// New fails when the collation isn't an identifier
+func WithCollation(collation string) SearchOption {
+	return func(search *Search) {
+		search.collation = collation
+	}
+}
+
+// WithDatabase sets the database connection of the searches declared on the configuration
+func WithDatabase(db *dbr.Dbr) SearchOption {
+	return func(search *Search) {
+		search.db = db
+	}
+}
+
+// WithElastic sets the elastic client of the searches declared on the configuration
+func WithElastic(client *elastic.Elastic) SearchOption {
+	return func(search *Search) {
+		search.elastic = client
+	}
+}
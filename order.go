@@ -1,5 +1,7 @@
 package search
 
+import "strings"
+
 type direction string
 
 const (
@@ -13,3 +15,56 @@ type order struct {
 }
 
 type orders []*order
+
+func (o orders) contains(column string) bool {
+	for _, item := range o {
+		if item.column == column {
+			return true
+		}
+	}
+	return false
+}
+
+func (o orders) reverse() orders {
+	reversed := make(orders, len(o))
+	for i, item := range o {
+		direction := orderAsc
+		if item.direction == orderAsc {
+			direction = orderDesc
+		}
+		reversed[i] = &order{column: item.column, direction: direction}
+	}
+	return reversed
+}
+
+// parseSort parses a sort parameter like "-age,first_name" against the sortable fields
+func parseSort(sort string, sortables map[string]string) (orders, []error) {
+	parsed := make(orders, 0)
+	errs := make([]error, 0)
+
+	for _, field := range strings.Split(sort, constValueSeparator) {
+		field = strings.TrimSpace(field)
+		if field == "" {
+			continue
+		}
+
+		direction := orderAsc
+		switch {
+		case strings.HasPrefix(field, constSortDesc):
+			direction = orderDesc
+			field = field[len(constSortDesc):]
+		case strings.HasPrefix(field, constSortAsc):
+			field = field[len(constSortAsc):]
+		}
+
+		column, ok := sortables[field]
+		if !ok {
+			errs = append(errs, newUnsupportedSortError(field))
+			continue
+		}
+
+		parsed = append(parsed, &order{column: column, direction: direction})
+	}
+
+	return parsed, errs
+}
@@ -0,0 +1,85 @@
+package search
+
+import (
+	goerrors "errors"
+	"reflect"
+	"testing"
+)
+
+func TestParseSort(t *testing.T) {
+	sortables := map[string]string{"age": "person.age", "first_name": "first_name"}
+
+	tests := []struct {
+		sort        string
+		expected    orders
+		unsupported []string
+	}{
+		{sort: "", expected: orders{}},
+		{sort: "-age,first_name", expected: orders{{column: "person.age", direction: orderDesc}, {column: "first_name", direction: orderAsc}}},
+		{sort: " +age , ,-first_name", expected: orders{{column: "person.age", direction: orderAsc}, {column: "first_name", direction: orderDesc}}},
+		{sort: "age,-password,person.age", expected: orders{{column: "person.age", direction: orderAsc}}, unsupported: []string{"password", "person.age"}},
+		{sort: "--age", expected: orders{}, unsupported: []string{"-age"}},
+	}
+
+	for _, test := range tests {
+		parsed, errs := parseSort(test.sort, sortables)
+		if !reflect.DeepEqual(parsed, test.expected) {
+			t.Errorf("sort %q: %+v, expected %+v", test.sort, parsed, test.expected)
+		}
+
+		unsupported := make([]string, 0)
+		for _, err := range errs {
+			var unsupportedSort *UnsupportedSortError
+			if !goerrors.As(err, &unsupportedSort) {
+				t.Errorf("sort %q: unexpected error %v", test.sort, err)
+				continue
+			}
+			unsupported = append(unsupported, unsupportedSort.Field)
+		}
+
+		if len(unsupported) != len(test.unsupported) || (len(unsupported) > 0 && !reflect.DeepEqual(unsupported, test.unsupported)) {
+			t.Errorf("sort %q: unsupported %v, expected %v", test.sort, unsupported, test.unsupported)
+		}
+	}
+}
+
+func TestSortQuery(t *testing.T) {
+	tests := []struct {
+		sort     string
+		expected []int
+		errs     int
+	}{
+		{sort: "-age,first_name", expected: []int{3, 4, 1, 5, 2}},
+		{sort: "age,-id", expected: []int{2, 5, 1, 4, 3}},
+		{sort: "last_name", errs: 1},
+		{sort: "id_person", errs: 1},
+	}
+
+	db := newPersons(t)
+	for _, test := range tests {
+		items := make([]*testPerson, 0)
+		_, errs := (&Search{}).NewDatabaseSearch(db.Select("*").From("person")).
+			Sortable("age", "age").
+			Sortable("first_name", "first_name").
+			Sortable("id", "id_person").
+			Query(map[string]string{constSort: test.sort}).
+			Bind(&items).
+			Exec()
+		if len(errs) != test.errs {
+			t.Fatalf("sort %s: errors %v, expected %d", test.sort, errs, test.errs)
+		}
+
+		if test.errs > 0 {
+			continue
+		}
+
+		ids := make([]int, len(items))
+		for i, item := range items {
+			ids[i] = item.IdPerson
+		}
+
+		if !reflect.DeepEqual(ids, test.expected) {
+			t.Errorf("sort %s: %v, expected %v", test.sort, ids, test.expected)
+		}
+	}
+}
@@ -0,0 +1,159 @@
+package search
+
+import (
+	"encoding/json"
+	goerrors "errors"
+	"net/http"
+	"strings"
+
+	"github.com/joaosoft/errors"
+	"github.com/joaosoft/web"
+)
+
+const (
+	constHeaderContentType     = "Content-Type"
+	constContentTypeProblem    = "application/problem+json"
+	constProblemTypeAboutBlank = "about:blank"
+)
+
+var statusByCode = map[string]int{
+	CodeInvalidParameter:   http.StatusBadRequest,
+	CodeUnknownFilter:      http.StatusBadRequest,
+	CodeUnsupportedSort:    http.StatusBadRequest,
+	CodeInvalidCursor:      http.StatusBadRequest,
+	CodeCursorWithoutOrder: http.StatusBadRequest,
+	CodeInvalidIdentifier:  http.StatusBadRequest,
+	CodeUnknownSearch:      http.StatusNotFound,
+	CodeBackendError:       http.StatusBadGateway,
+	CodeBackendUnavailable: http.StatusServiceUnavailable,
+	CodeTimeout:            http.StatusGatewayTimeout,
+}
+
+// Problem is a RFC 7807 problem details body of the search errors
+type Problem struct {
+	Type          string          `json:"type"`
+	Title         string          `json:"title"`
+	Status        int             `json:"status"`
+	Detail        string          `json:"detail,omitempty"`
+	Code          string          `json:"code,omitempty"`
+	InvalidParams []*ProblemParam `json:"invalid_params,omitempty"`
+}
+
+// ProblemParam is an invalid query parameter of a problem
+type ProblemParam struct {
+	Name   string `json:"name"`
+	Value  string `json:"value,omitempty"`
+	Reason string `json:"reason"`
+}
+
+// ErrorCode returns the code of the kind of a search error, or an empty code for other errors
+func ErrorCode(err error) string {
+	var coded interface{ Code() string }
+	if goerrors.As(err, &coded) {
+		return coded.Code()
+	}
+
+	var joaosoftErr *errors.Error
+	if goerrors.As(err, &joaosoftErr) {
+		if code, ok := joaosoftErr.Code.(string); ok {
+			return code
+		}
+	}
+
+	return ""
+}
+
+// StatusCode maps the errors to the most severe of their HTTP status codes
+func StatusCode(errs ...error) int {
+	status := 0
+	for _, err := range errs {
+		errStatus, ok := statusByCode[ErrorCode(err)]
+		if !ok {
+			errStatus = http.StatusInternalServerError
+		}
+
+		if errStatus > status {
+			status = errStatus
+		}
+	}
+
+	if status == 0 {
+		return http.StatusInternalServerError
+	}
+
+	return status
+}
+
+// NewProblem creates the problem details of the errors, with the invalid query parameters among them
+func NewProblem(errs ...error) *Problem {
+	status := StatusCode(errs...)
+	problem := &Problem{
+		Type:   constProblemTypeAboutBlank,
+		Title:  http.StatusText(status),
+		Status: status,
+	}
+
+	details := make([]string, 0, len(errs))
+	for _, err := range errs {
+		details = append(details, err.Error())
+
+		if problem.Code == "" && statusByCode[ErrorCode(err)] == status {
+			problem.Code = ErrorCode(err)
+		}
+
+		if param := newProblemParam(err); param != nil {
+			problem.InvalidParams = append(problem.InvalidParams, param)
+		}
+	}
+	problem.Detail = strings.Join(details, "; ")
+
+	return problem
+}
+
+func newProblemParam(err error) *ProblemParam {
+	var invalidParameter *InvalidParameterError
+	var unknownFilter *UnknownFilterError
+	var unsupportedSort *UnsupportedSortError
+	var invalidCursor *InvalidCursorError
+
+	switch {
+	case goerrors.As(err, &invalidParameter):
+		return &ProblemParam{Name: invalidParameter.Parameter, Value: invalidParameter.Value, Reason: invalidParameter.Reason}
+	case goerrors.As(err, &unknownFilter):
+		return &ProblemParam{Name: unknownFilter.Parameter, Reason: "unknown filter"}
+	case goerrors.As(err, &unsupportedSort):
+		return &ProblemParam{Name: constSort, Value: unsupportedSort.Field, Reason: "unsupported sort field"}
+	case goerrors.As(err, &invalidCursor):
+		return &ProblemParam{Name: constCursor, Value: invalidCursor.Cursor, Reason: "invalid cursor"}
+	}
+
+	return nil
+}
+
+// WriteProblem writes the errors as a problem+json body with their HTTP status code
+func WriteProblem(writer http.ResponseWriter, errs ...error) error {
+	problem := NewProblem(errs...)
+
+	body, err := json.Marshal(problem)
+	if err != nil {
+		return err
+	}
+
+	writer.Header().Set(constHeaderContentType, constContentTypeProblem)
+	writer.WriteHeader(problem.Status)
+	_, err = writer.Write(body)
+
+	return err
+}
+
+// WriteContextProblem writes the errors as a problem+json body with their HTTP status code on the response of the web context
+func WriteContextProblem(ctx *web.Context, errs ...error) error {
+	problem := NewProblem(errs...)
+
+	body, err := json.Marshal(problem)
+	if err != nil {
+		return err
+	}
+
+	return ctx.Response.Bytes(web.Status(problem.Status), web.ContentType(constContentTypeProblem), body)
+}
@@ -0,0 +1,82 @@
+package search
+
+import (
+	"encoding/json"
+	goerrors "errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestWriteProblem(t *testing.T) {
+	tests := []struct {
+		err    error
+		status int
+		code   string
+		param  *ProblemParam
+	}{
+		{err: newInvalidParameterError("age", "x", "must be an integer"), status: http.StatusBadRequest, code: CodeInvalidParameter, param: &ProblemParam{Name: "age", Value: "x", Reason: "must be an integer"}},
+		{err: newUnknownFilterError("color"), status: http.StatusBadRequest, code: CodeUnknownFilter, param: &ProblemParam{Name: "color", Reason: "unknown filter"}},
+		{err: newUnsupportedSortError("age"), status: http.StatusBadRequest, code: CodeUnsupportedSort, param: &ProblemParam{Name: constSort, Value: "age", Reason: "unsupported sort field"}},
+		{err: newInvalidCursorError("abc"), status: http.StatusBadRequest, code: CodeInvalidCursor, param: &ProblemParam{Name: constCursor, Value: "abc", Reason: "invalid cursor"}},
+		{err: ErrorCursorWithoutOrder, status: http.StatusBadRequest, code: CodeCursorWithoutOrder},
+		{err: newInvalidIdentifierError("name;--"), status: http.StatusBadRequest, code: CodeInvalidIdentifier},
+		{err: newUnknownSearchError("persons"), status: http.StatusNotFound, code: CodeUnknownSearch},
+		{err: newBackendUnavailableError(constBackendDatabase, goerrors.New("connection refused")), status: http.StatusServiceUnavailable, code: CodeBackendUnavailable},
+		{err: newBackendError(constBackendElastic, http.StatusBadRequest, "parsing_exception: unknown query"), status: http.StatusBadGateway, code: CodeBackendError},
+		{err: ErrorCircuitOpen, status: http.StatusServiceUnavailable, code: CodeBackendUnavailable},
+		{err: newTimeoutError(constBackendElastic, time.Second, goerrors.New("deadline exceeded")), status: http.StatusGatewayTimeout, code: CodeTimeout},
+		{err: goerrors.New("unexpected"), status: http.StatusInternalServerError, code: ""},
+	}
+
+	for _, test := range tests {
+		recorder := httptest.NewRecorder()
+		if err := WriteProblem(recorder, test.err); err != nil {
+			t.Fatal(err)
+		}
+
+		if recorder.Code != test.status {
+			t.Errorf("%s: status %d, expected %d", test.err, recorder.Code, test.status)
+		}
+
+		if contentType := recorder.Header().Get(constHeaderContentType); contentType != constContentTypeProblem {
+			t.Errorf("%s: content type %s", test.err, contentType)
+		}
+
+		problem := &Problem{}
+		if err := json.Unmarshal(recorder.Body.Bytes(), problem); err != nil {
+			t.Fatal(err)
+		}
+
+		if problem.Status != test.status || problem.Code != test.code || problem.Title != http.StatusText(test.status) {
+			t.Errorf("%s: problem %+v, expected the status %d and the code %q", test.err, problem, test.status, test.code)
+		}
+
+		switch {
+		case test.param == nil && len(problem.InvalidParams) > 0:
+			t.Errorf("%s: unexpected invalid params %+v", test.err, problem.InvalidParams[0])
+		case test.param != nil && (len(problem.InvalidParams) != 1 || *problem.InvalidParams[0] != *test.param):
+			t.Errorf("%s: invalid params %+v, expected %+v", test.err, problem.InvalidParams, test.param)
+		}
+	}
+}
+
+// TestStatusCode checks that the most severe status is returned for several errors
+func TestStatusCode(t *testing.T) {
+	tests := []struct {
+		errs   []error
+		status int
+	}{
+		{errs: nil, status: http.StatusInternalServerError},
+		{errs: []error{newUnknownFilterError("a"), newUnsupportedSortError("b")}, status: http.StatusBadRequest},
+		{errs: []error{newUnknownFilterError("a"), newTimeoutError(constBackendDatabase, time.Second, goerrors.New("timeout"))}, status: http.StatusGatewayTimeout},
+		{errs: []error{newBackendUnavailableError(constBackendDatabase, goerrors.New("down")), newTimeoutError(constBackendElastic, time.Second, goerrors.New("timeout"))}, status: http.StatusGatewayTimeout},
+	}
+
+	for _, test := range tests {
+		if status := StatusCode(test.errs...); status != test.status {
+			t.Errorf("%v: status %d, expected %d", test.errs, status, test.status)
+		}
+	}
+}
@@ -0,0 +1,15 @@
+-- migrate up
+
+CREATE EXTENSION IF NOT EXISTS pg_trgm;
+
+-- the trigram indexes serve the ILIKE and % conditions of the fuzzy search, the % condition is only used for
+-- similarity thresholds from 0.3 (the default pg_trgm.similarity_threshold), the lower ones scan the table
+
+CREATE INDEX person_first_name_trgm_idx ON search.person USING GIN (first_name gin_trgm_ops);
+CREATE INDEX person_last_name_trgm_idx ON search.person USING GIN (last_name gin_trgm_ops);
+
+
+-- migrate down
+DROP INDEX search.person_first_name_trgm_idx;
+DROP INDEX search.person_last_name_trgm_idx;
+DROP EXTENSION IF EXISTS pg_trgm;
@@ -1,6 +1,9 @@
 package search
 
 import (
+	"sync"
+	"time"
+
 	"github.com/joaosoft/dbr"
 	"github.com/joaosoft/elastic"
 	"github.com/joaosoft/logger"
@@ -9,24 +12,44 @@ import (
 )
 
 type Search struct {
-	maxSize       int
-	config        *SearchConfig
-	isLogExternal bool
-	pm            *manager.Manager
-	logger        logger.ILogger
+	maxSize          int
+	timeout          time.Duration
+	breakerThreshold int
+	breakerCooldown  time.Duration
+	breakers         map[string]*circuitBreaker
+	shadowRate       float64
+	shadowLimit      int
+	shadowSlots      chan struct{}
+	collation        string
+	db               *dbr.Dbr
+	elastic          *elastic.Elastic
+	definitions      map[string]*SearchDefinition
+	mux              sync.Mutex
+	config           *SearchConfig
+	isLogExternal    bool
+	pm               *manager.Manager
+	logger           logger.ILogger
 }
 
 type searchResult struct {
+	Backend    string      `json:"backend,omitempty"`
 	Result     interface{} `json:"result"`
 	Metadata   interface{} `json:"Metadata,omitempty"`
 	Pagination *pagination `json:"pagination,omitempty"`
 }
 
 type pagination struct {
-	First    *string `json:"first"`
-	Previous *string `json:"previous"`
-	Next     *string `json:"next"`
-	Last     *string `json:"last"`
+	First          *string `json:"first"`
+	Previous       *string `json:"previous"`
+	Next           *string `json:"next"`
+	Last           *string `json:"last"`
+	PreviousCursor *string `json:"previous_cursor,omitempty"`
+	NextCursor     *string `json:"next_cursor,omitempty"`
+	Total          *int    `json:"total,omitempty"`
+	TotalPages     *int    `json:"total_pages,omitempty"`
+	Page           int     `json:"page,omitempty"`
+	Size           int     `json:"size"`
+	IsTotalExact   bool    `json:"is_total_exact"`
 }
 
 // New ...
@@ -54,6 +77,33 @@ func New(options ...SearchOption) (*Search, error) {
 
 	search.Reconfigure(options...)
 
+	// the collation is written on the queries
+	if search.collation != "" && !identifierRegex.MatchString(search.collation) {
+		return nil, newInvalidIdentifierError(search.collation)
+	}
+
+	// default timeout
+	if search.timeout == 0 && search.config != nil && search.config.Timeout != "" {
+		if search.timeout, err = time.ParseDuration(search.config.Timeout); err != nil {
+			search.logger.Errorf("invalid search timeout %s: %s", search.config.Timeout, err)
+		}
+	}
+
+	// circuit breaker
+	if search.breakerThreshold == 0 && search.config != nil && search.config.CircuitBreaker != nil {
+		search.breakerThreshold = search.config.CircuitBreaker.Threshold
+		if search.breakerCooldown, err = time.ParseDuration(search.config.CircuitBreaker.Cooldown); err != nil {
+			search.logger.Errorf("invalid circuit breaker cooldown %s: %s", search.config.CircuitBreaker.Cooldown, err)
+		}
+	}
+
+	// declared searches
+	if search.config != nil {
+		if search.definitions, err = search.newDefinitions(search.config.Searches); err != nil {
+			return nil, err
+		}
+	}
+
 	// execute migrations
 	if search.config.Migration != nil {
 		migrationService, err := migration.NewCmdService(migration.WithCmdConfiguration(search.config.Migration))
@@ -1,20 +1,83 @@
 package search
 
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
 type searchClient interface {
-	Exec(searchData *searchData) (int, error)
+	Exec(ctx context.Context, searchData *searchData) (int, error)
+	backend() string
 }
 
 type searchData struct {
-	hasPagination bool
-	hasMetadata   bool
-	path          string
-	query         map[string]string
-	search        *string
-	filters       map[string]string
-	searchFilters []string
-	orders        orders
-	page          int
-	size          int
-	object        interface{}
-	metadata      map[string]*Metadata
+	hasPagination  bool
+	paginationMode paginationMode
+	cursor         *cursor
+	nextCursor     string
+	previousCursor string
+	hasTotal       bool
+	isTotalExact   bool
+	hasMetadata    bool
+	path           string
+	query          conditions
+	values         url.Values
+	sorts          []string
+	search         *string
+	searchQuery    searchQuery
+	filters        map[string]string
+	searchFilters  []string
+	searchFields   searchFields
+	fullText       *fullText
+	fuzzy          *fuzzy
+	orders         orders
+	page           int
+	size           int
+	object         interface{}
+	metadata       map[string]*Metadata
+}
+
+// link builds a pagination link with the effective query (filters, search, sort and size),
+// merged with the query already present on the path
+func (searchData *searchData) link(params url.Values) string {
+	path := searchData.path
+	query := make(url.Values)
+
+	if index := strings.Index(path, "?"); index > -1 {
+		query, _ = url.ParseQuery(path[index+1:])
+		path = path[:index]
+	}
+
+	for _, key := range []string{constPage, constSize, constSearch, constSort, constCursor} {
+		query.Del(key)
+	}
+
+	for key, values := range searchData.values {
+		query[key] = values
+	}
+
+	if searchData.search != nil {
+		query.Set(constSearch, *searchData.search)
+	}
+
+	if len(searchData.sorts) > 0 {
+		query.Set(constSort, strings.Join(searchData.sorts, constValueSeparator))
+	}
+
+	if searchData.size > 0 {
+		query.Set(constSize, strconv.Itoa(searchData.size))
+	}
+
+	for key, values := range params {
+		query[key] = values
+	}
+
+	if len(query) == 0 {
+		return path
+	}
+
+	return fmt.Sprintf("%s?%s", path, query.Encode())
 }
@@ -0,0 +1,44 @@
+package search
+
+import (
+	"net/url"
+	"testing"
+)
+
+func TestLink(t *testing.T) {
+	search := "joao ribeiro & co"
+
+	tests := []struct {
+		name       string
+		searchData *searchData
+		params     url.Values
+		expected   string
+	}{
+		{name: "path only", searchData: &searchData{path: "/persons"}, params: url.Values{}, expected: "/persons"},
+		{name: "page and size", searchData: &searchData{path: "/persons", size: 10}, params: url.Values{constPage: {"2"}}, expected: "/persons?page=2&size=10"},
+		{
+			name:       "filters, search and sort",
+			searchData: &searchData{path: "/persons", size: 10, values: url.Values{"age[gte]": {"30"}, "status": {"open", "closed"}}, search: &search, sorts: []string{"-age", "first_name"}},
+			params:     url.Values{constPage: {"3"}},
+			expected:   "/persons?age%5Bgte%5D=30&page=3&search=joao+ribeiro+%26+co&size=10&sort=-age%2Cfirst_name&status=open&status=closed",
+		},
+		{
+			name:       "query of the path",
+			searchData: &searchData{path: "/persons?tenant=a&page=7&sort=age", size: 5},
+			params:     url.Values{constPage: {"2"}},
+			expected:   "/persons?page=2&size=5&tenant=a",
+		},
+		{
+			name:       "cursor",
+			searchData: &searchData{path: "/persons", size: 5, sorts: []string{"age"}},
+			params:     url.Values{constCursor: {"eyJ2IjpbMzBdfQ"}},
+			expected:   "/persons?cursor=eyJ2IjpbMzBdfQ&size=5&sort=age",
+		},
+	}
+
+	for _, test := range tests {
+		if link := test.searchData.link(test.params); link != test.expected {
+			t.Errorf("%s: %s, expected %s", test.name, link, test.expected)
+		}
+	}
+}
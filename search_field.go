@@ -0,0 +1,82 @@
+package search
+
+import "strconv"
+
+type match string
+
+const (
+	matchContains match = "contains"
+	matchPrefix   match = "prefix"
+	matchExact    match = "exact"
+	matchFuzzy    match = "fuzzy"
+
+	constDefaultBoost = 1
+
+	// constDefaultSimilarity is the default similarity threshold of pg_trgm
+	constDefaultSimilarity = 0.3
+)
+
+// searchField is the weight of the matches of a search field on the relevance and how the terms are matched
+type searchField struct {
+	boost float64
+	match match
+}
+
+type searchFields map[string]*searchField
+
+// field returns the search field with the name, by default with a boost of 1 matching the values containing the terms
+func (fields searchFields) field(name string) *searchField {
+	if field, ok := fields[name]; ok {
+		return field
+	}
+
+	return &searchField{boost: constDefaultBoost, match: matchContains}
+}
+
+// matchByName returns the match strategy with the name, the contains strategy when it's empty
+func matchByName(name string) (match, bool) {
+	switch match(name) {
+	case "":
+		return matchContains, true
+	case matchContains, matchPrefix, matchExact, matchFuzzy:
+		return match(name), true
+	}
+
+	return "", false
+}
+
+// fuzzy is the typo tolerant search, matching the values similar to the terms with the fuzziness of elastic
+// and with the trigram similarity of postgres (pg_trgm) above the threshold
+type fuzzy struct {
+	threshold float64
+}
+
+func newFuzzy(threshold ...float64) *fuzzy {
+	fuzzy := &fuzzy{threshold: constDefaultSimilarity}
+	if len(threshold) > 0 && threshold[0] > 0 {
+		fuzzy.threshold = threshold[0]
+	}
+	return fuzzy
+}
+
+// matchOf returns the match strategy of a search field, the fields containing the terms
+// are matched as fuzzy on a fuzzy search
+func (searchData *searchData) matchOf(field string) match {
+	match := searchData.searchFields.field(field).match
+	if match == matchContains && searchData.fuzzy != nil {
+		return matchFuzzy
+	}
+	return match
+}
+
+// similarity returns the similarity threshold of the fuzzy fields
+func (searchData *searchData) similarity() float64 {
+	if searchData.fuzzy != nil {
+		return searchData.fuzzy.threshold
+	}
+	return constDefaultSimilarity
+}
+
+func formatNumber(number float64) string {
+	return strconv.FormatFloat(number, 'f', -1, 64)
+}
@@ -1,33 +1,58 @@
 package search
 
 import (
+	"context"
+	goerrors "errors"
 	"fmt"
 	"html"
 	"math"
+	"net/http"
+	"net/url"
 	"reflect"
+	"sort"
 	"strconv"
-)
+	"strings"
+	"time"
 
-type fallback interface {
-	Exec() (*searchResult, []error)
-}
+	"github.com/joaosoft/logger"
+)
 
 type searchHandler struct {
-	client        searchClient
-	hasPagination bool
-	hasMetadata   bool
-	path          string
-	query         map[string]string
-	search        *string
-	filters       map[string]string
-	searchFilters []string
-	metadata      map[string]*Metadata
-	orders        orders
-	page          int
-	size          int
-	maxSize       int
-	object        interface{}
-	fallback      fallback
+	client         searchClient
+	name           string
+	hasPagination  bool
+	paginationMode paginationMode
+	cursor         string
+	tiebreaker     string
+	hasMetadata    bool
+	path           string
+	values         url.Values
+	search         *string
+	filters        map[string]string
+	filterTypes    map[string]*ValueType
+	strictFilters  bool
+	searchFilters  []string
+	searchFields   searchFields
+	fullText       *fullText
+	fuzzy          *fuzzy
+	sortables      map[string]string
+	sorts          []string
+	metadata       map[string]*Metadata
+	orders         orders
+	page           int
+	size           int
+	maxSize        int
+	timeout        time.Duration
+	breaker        *circuitBreaker
+	breakers       func(name string) *circuitBreaker
+	shadow         *shadow
+	shadowRate     float64
+	shadowSlots    chan struct{}
+	hedgeDelay     time.Duration
+	logger         logger.ILogger
+	object         interface{}
+	fallbacks      []*fallbackItem
+	errs           []error
 }
 
 type metadataFunction func(result interface{}, object interface{}, metadata map[string]*Metadata) error
@@ -41,36 +66,88 @@ type Metadata struct {
 func (search *Search) newSearchHandler(client searchClient) *searchHandler {
 	return &searchHandler{
 		client:        client,
-		query:         make(map[string]string),
+		name:          client.backend(),
+		values:        make(url.Values),
 		filters:       make(map[string]string),
+		filterTypes:   make(map[string]*ValueType),
 		searchFilters: make([]string, 0),
+		searchFields:  make(searchFields),
+		sortables:     make(map[string]string),
 		metadata:      make(map[string]*Metadata),
 		hasPagination: true,
 		hasMetadata:   true,
+		maxSize:       search.maxSize,
+		timeout:       search.timeout,
+		breaker:       search.circuitBreaker(client.backend()),
+		breakers:      search.circuitBreaker,
+		shadowRate:    search.shadowRate,
+		shadowSlots:   search.shadows(),
+		logger:        search.logger,
 	}
 }
 
 func (searchHandler *searchHandler) Query(query map[string]string) *searchHandler {
+	values := make(url.Values)
 	for key, value := range query {
-		value = html.UnescapeString(value)
+		values.Set(key, value)
+	}
+
+	return searchHandler.QueryValues(values)
+}
+
+func (searchHandler *searchHandler) QueryValues(values url.Values) *searchHandler {
+	for key, items := range values {
+		if len(items) == 0 {
+			continue
+		}
+
+		unescaped := make([]string, len(items))
+		for i, item := range items {
+			unescaped[i] = html.UnescapeString(item)
+		}
+		value := unescaped[0]
 
 		switch key {
 		case constPage:
-			searchHandler.page, _ = strconv.Atoi(value)
+			searchHandler.page = searchHandler.parseNumber(key, value)
 		case constSize:
-			searchHandler.size, _ = strconv.Atoi(value)
+			// a size of 0 keeps the default size
+			if size := searchHandler.parseNumber(key, value); size > 0 {
+				searchHandler.size = size
+			}
 		case constSearch:
 			searchHandler.search = &value
+		case constSort:
+			searchHandler.sorts = append(searchHandler.sorts, unescaped...)
+		case constCursor:
+			searchHandler.cursor = value
 		default:
-			if filter, ok := searchHandler.filters[key]; ok {
-				searchHandler.query[key] = filter
-			}
+			searchHandler.values[key] = append(searchHandler.values[key], unescaped...)
 		}
 	}
 
 	return searchHandler
 }
 
+// parseNumber parses the page and size parameters, keeping an error to be returned on Exec when they aren't valid
+func (searchHandler *searchHandler) parseNumber(key string, value string) int {
+	number, err := strconv.Atoi(value)
+	if err != nil || number < 0 {
+		searchHandler.errs = append(searchHandler.errs, newInvalidParameterError(key, value, "must be a positive integer"))
+		return 0
+	}
+
+	return number
+}
+
+func (searchHandler *searchHandler) Request(request *http.Request) *searchHandler {
+	if searchHandler.path == "" {
+		searchHandler.path = request.URL.Path
+	}
+
+	return searchHandler.QueryValues(request.URL.Query())
+}
+
 func (searchHandler *searchHandler) Filters(fields ...string) *searchHandler {
 	for _, field := range fields {
 		searchHandler.filters[field] = field
@@ -83,16 +160,97 @@ func (searchHandler *searchHandler) Filter(searchName string, internalName strin
 	return searchHandler
 }
 
+// FilterType sets the type of the values of a filter, validated and converted before the search is executed
+func (searchHandler *searchHandler) FilterType(searchName string, valueType *ValueType) *searchHandler {
+	searchHandler.filterTypes[searchName] = valueType
+	return searchHandler
+}
+
+// StrictFilters rejects the query parameters that aren't filters or have invalid values,
+// that are otherwise ignored
+func (searchHandler *searchHandler) StrictFilters() *searchHandler {
+	searchHandler.strictFilters = true
+	return searchHandler
+}
+
 func (searchHandler *searchHandler) SearchFilters(fields ...string) *searchHandler {
 	searchHandler.searchFilters = append(searchHandler.searchFilters, fields...)
 	return searchHandler
 }
 
+// SearchField adds a search field with the weight of its matches on the "relevance" sortable and
+// the strategy matching the terms ("contains", "prefix", "exact" or "fuzzy")
+func (searchHandler *searchHandler) SearchField(field string, boost float64, match match) *searchHandler {
+	searchHandler.searchFilters = append(searchHandler.searchFilters, field)
+	searchHandler.searchFields[field] = &searchField{boost: boost, match: match}
+	searchHandler.sortables[constRelevance] = constRelevanceColumn
+	return searchHandler
+}
+
+// FuzzySearch tolerates typos on the search fields matching the values containing the terms, with the fuzziness
+// of elastic or the pg_trgm similarity above the threshold on postgres (0.3 by default), ranked on the "relevance" sortable
+func (searchHandler *searchHandler) FuzzySearch(threshold ...float64) *searchHandler {
+	searchHandler.fuzzy = newFuzzy(threshold...)
+	searchHandler.sortables[constRelevance] = constRelevanceColumn
+	return searchHandler
+}
+
+// FullTextSearch searches with the postgres full text search on the given text search configuration,
+// over the search fields or a tsvector column, and adds the "relevance" sortable ranking the results
+func (searchHandler *searchHandler) FullTextSearch(config string, vectorColumn ...string) *searchHandler {
+	searchHandler.fullText = newFullText(config, vectorColumn...)
+	searchHandler.sortables[constRelevance] = constRelevanceColumn
+	return searchHandler
+}
+
+func (searchHandler *searchHandler) Sortable(searchName string, internalName string) *searchHandler {
+	searchHandler.sortables[searchName] = internalName
+	return searchHandler
+}
+
+// FromModel registers the filters, search fields and sortables of the model fields
+// tagged with `search:"filter,search,sort"`, mapping the json names to the columns or fields of the backend
+func (searchHandler *searchHandler) FromModel(model interface{}) *searchHandler {
+	for _, field := range modelFields(model, tagOfBackend(searchHandler.client.backend())) {
+		if field.filter {
+			searchHandler.Filter(field.name, field.column)
+			if field.valueType != nil {
+				searchHandler.FilterType(field.name, field.valueType)
+			}
+		}
+		if field.searchable {
+			if field.searchField != nil {
+				searchHandler.SearchField(field.column, field.searchField.boost, field.searchField.match)
+			} else {
+				searchHandler.SearchFilters(field.column)
+			}
+		}
+		if field.sortable {
+			searchHandler.Sortable(field.name, field.column)
+		}
+	}
+	return searchHandler
+}
+
 func (searchHandler *searchHandler) WithoutPagination() *searchHandler {
 	searchHandler.hasPagination = false
 	return searchHandler
 }
 
+// WithCursorPagination pages the results with an opaque cursor built from the sort values of the
+// edge rows instead of page numbers, the last order should be unique to have a stable cursor
+func (searchHandler *searchHandler) WithCursorPagination() *searchHandler {
+	searchHandler.paginationMode = paginationModeCursor
+	return searchHandler
+}
+
+// Tiebreaker sets a unique field added as the last order on cursor pagination, so rows
+// sharing the same sort values aren't skipped or repeated between pages
+func (searchHandler *searchHandler) Tiebreaker(field string) *searchHandler {
+	searchHandler.tiebreaker = field
+	return searchHandler
+}
+
 func (searchHandler *searchHandler) WithoutMetadata() *searchHandler {
 	searchHandler.hasMetadata = false
 	return searchHandler
@@ -121,6 +279,11 @@ func (searchHandler *searchHandler) Search(value string) *searchHandler {
 	return searchHandler
 }
 
+func (searchHandler *searchHandler) Cursor(cursor string) *searchHandler {
+	searchHandler.cursor = cursor
+	return searchHandler
+}
+
 func (searchHandler *searchHandler) Page(page int) *searchHandler {
 	searchHandler.page = page
 	return searchHandler
@@ -146,47 +309,132 @@ func (searchHandler *searchHandler) Bind(object interface{}) *searchHandler {
 	return searchHandler
 }
 
-func (searchHandler *searchHandler) Fallback(fallback fallback) *searchHandler {
-	searchHandler.fallback = fallback
+// Fallback adds a fallback to the chain, executed in order while the previous one fails
+// and the policy accepts its error (by default, all the errors except the validation ones)
+func (searchHandler *searchHandler) Fallback(fallback fallback, policy ...FallbackPolicy) *searchHandler {
+	item := &fallbackItem{fallback: fallback, policy: FallbackOnBackendError}
+	if len(policy) > 0 {
+		item.policy = policy[0]
+	}
+
+	searchHandler.fallbacks = append(searchHandler.fallbacks, item)
+	return searchHandler
+}
+
+// CircuitBreaker uses the circuit breaker with the name instead of the one shared by all the searches on the backend,
+// so a search on other connection (as a read replica on a fallback) isn't blocked when the circuit of the first one is open,
+// the name is reported as the backend of its results
+func (searchHandler *searchHandler) CircuitBreaker(name string) *searchHandler {
+	searchHandler.name = name
+	searchHandler.breaker = searchHandler.breakers(name)
+	return searchHandler
+}
+
+// Timeout sets the deadline of the search, overriding the default timeout of the configuration
+func (searchHandler *searchHandler) Timeout(timeout time.Duration) *searchHandler {
+	searchHandler.timeout = timeout
 	return searchHandler
 }
 
 func (searchHandler *searchHandler) Exec() (*searchResult, []error) {
+	return searchHandler.ExecContext(context.Background())
+}
 
-	if searchHandler.maxSize > 0 && searchHandler.size > searchHandler.maxSize {
-		searchHandler.size = searchHandler.maxSize
+func (searchHandler *searchHandler) ExecContext(ctx context.Context) (*searchResult, []error) {
+	if searchHandler.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, searchHandler.timeout)
+		defer cancel()
+	}
+
+	size := searchHandler.size
+	if searchHandler.maxSize > 0 && (size <= 0 || size > searchHandler.maxSize) {
+		size = searchHandler.maxSize
+	}
+
+	page := searchHandler.page
+	if page < 1 {
+		page = 1
+	}
+
+	// the invalid parameters are returned together
+	errs := append([]error{}, searchHandler.errs...)
+
+	orders, errsSort := parseSort(strings.Join(searchHandler.sorts, constValueSeparator), searchHandler.sortables)
+	errs = append(errs, errsSort...)
+
+	orders = append(orders, searchHandler.orders...)
+
+	if searchHandler.paginationMode == paginationModeCursor && searchHandler.tiebreaker != "" && !orders.contains(searchHandler.tiebreaker) {
+		orders = append(orders, &order{column: searchHandler.tiebreaker, direction: orderAsc})
+	}
+
+	var cursor *cursor
+	if searchHandler.paginationMode == paginationModeCursor && searchHandler.cursor != "" {
+		var err error
+		if cursor, err = decodeCursor(searchHandler.cursor); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	var searchQuery searchQuery
+	if searchHandler.search != nil {
+		searchQuery = parseSearch(*searchHandler.search, searchHandler.searchFilters)
+	}
+
+	query, values, errsConditions := searchHandler.conditions()
+	errs = append(errs, errsConditions...)
+
+	if len(errs) > 0 {
+		return nil, errs
 	}
 
 	searchData := &searchData{
-		hasPagination: searchHandler.hasPagination,
-		hasMetadata:   searchHandler.hasMetadata,
-		path:          searchHandler.path,
-		query:         searchHandler.query,
-		search:        searchHandler.search,
-		filters:       searchHandler.filters,
-		searchFilters: searchHandler.searchFilters,
-		orders:        searchHandler.orders,
-		page:          searchHandler.page,
-		size:          searchHandler.size,
-		object:        searchHandler.object,
-		metadata:      searchHandler.metadata,
-	}
-	total, err := searchHandler.client.Exec(searchData)
-
-	if err != nil {
-
-		if searchHandler.fallback == nil {
-			return nil, []error{err}
-		}
-
-		if result, errFallback := searchHandler.fallback.Exec(); err != nil {
-			return nil, append(append([]error{}, err), errFallback...)
-		} else {
-			return result, nil
+		hasPagination:  searchHandler.hasPagination,
+		paginationMode: searchHandler.paginationMode,
+		cursor:         cursor,
+		hasMetadata:    searchHandler.hasMetadata,
+		path:           searchHandler.path,
+		query:          query,
+		values:         values,
+		sorts:          searchHandler.sorts,
+		search:         searchHandler.search,
+		searchQuery:    searchQuery,
+		filters:        searchHandler.filters,
+		searchFilters:  searchHandler.searchFilters,
+		searchFields:   searchHandler.searchFields,
+		fullText:       searchHandler.fullText,
+		fuzzy:          searchHandler.fuzzy,
+		orders:         orders,
+		page:           page,
+		size:           size,
+		object:         searchHandler.object,
+		metadata:       searchHandler.metadata,
+	}
+	var result *searchResult
+	if searchHandler.hedgeDelay > 0 && len(searchHandler.fallbacks) > 0 {
+		var errs []error
+		if result, errs = searchHandler.execHedged(ctx, searchData); len(errs) > 0 {
+			return nil, errs
+		}
+	} else {
+		total, err := searchHandler.execClient(ctx, searchData)
+		if err != nil {
+			return searchHandler.execFallbacks(ctx, err, searchHandler.fallbacks)
 		}
+		result = searchHandler.newResult(searchData, total)
+	}
 
+	// shadow
+	// only the results of this handler, not of a fallback on the same backend
+	if searchHandler.shadow != nil && result.Backend == searchHandler.name {
+		searchHandler.shadow.run(result)
 	}
 
+	return result, nil
+}
+
+func (searchHandler *searchHandler) newResult(searchData *searchData, total int) *searchResult {
 	// Metadata
 	var metadata map[string]interface{}
 	if searchHandler.hasMetadata {
@@ -199,52 +447,219 @@ func (searchHandler *searchHandler) Exec() (*searchResult, []error) {
 	// pagination
 	var pagination *pagination
 	if searchHandler.hasPagination {
-		pagination = newPagination(searchData, total)
+		switch searchHandler.paginationMode {
+		case paginationModeCursor:
+			pagination = newCursorPagination(searchData, total)
+		default:
+			pagination = newPagination(searchData, total)
+		}
 	}
 
 	// result
 	return &searchResult{
+		Backend:    searchHandler.name,
 		Result:     searchHandler.object,
 		Metadata:   metadata,
 		Pagination: pagination,
-	}, nil
+	}
+}
+
+// execClient executes the search on the backend, unless its circuit breaker is open
+func (searchHandler *searchHandler) execClient(ctx context.Context, searchData *searchData) (int, error) {
+	if searchHandler.breaker == nil {
+		total, err := searchHandler.client.Exec(ctx, searchData)
+		return total, searchHandler.backendError(err)
+	}
+
+	if !searchHandler.breaker.allow() {
+		return 0, searchHandler.backendError(ErrorCircuitOpen)
+	}
+
+	total, err := searchHandler.client.Exec(ctx, searchData)
+	switch {
+	case err == nil:
+		searchHandler.breaker.success()
+	case isValidationError(err) || goerrors.Is(err, context.Canceled):
+		// rejected before reaching the backend or cancelled by the caller, it doesn't tell anything about the backend
+		searchHandler.breaker.release()
+	case isUnavailableError(err):
+		searchHandler.breaker.failure()
+	default:
+		// the other errors may be caused by the request (as an invalid value on a filter without type),
+		// counting them would let the clients open the circuit of all the searches
+		searchHandler.breaker.release()
+	}
+
+	return total, searchHandler.backendError(err)
+}
+
+// backendError types the errors of the backend as a timeout or as an unavailable backend
+func (searchHandler *searchHandler) backendError(err error) error {
+	var unavailable *BackendUnavailableError
+	var timeout *TimeoutError
+
+	switch {
+	case err == nil || isValidationError(err) || goerrors.Is(err, context.Canceled):
+		return err
+	case goerrors.As(err, &unavailable) || goerrors.As(err, &timeout):
+		// already typed by the client
+		return err
+	case goerrors.Is(err, context.DeadlineExceeded):
+		return newTimeoutError(searchHandler.client.backend(), searchHandler.timeout, err)
+	case isUnavailableError(err):
+		return newBackendUnavailableError(searchHandler.client.backend(), err)
+	}
+
+	return err
+}
+
+// execFallbacks runs the fallback chain until one of them answers
+func (searchHandler *searchHandler) execFallbacks(ctx context.Context, err error, fallbacks []*fallbackItem) (*searchResult, []error) {
+	errs := []error{err}
+
+	for _, item := range fallbacks {
+		if !item.policy(err) {
+			continue
+		}
+
+		result, errFallback := item.exec(ctx)
+		if len(errFallback) == 0 {
+			return result, nil
+		}
+
+		errs = append(errs, errFallback...)
+		err = errFallback[len(errFallback)-1]
+	}
+
+	return nil, errs
+}
+
+// conditions resolves the query values against the registered filters, returning the applied values
+func (searchHandler *searchHandler) conditions() (conditions, url.Values, []error) {
+	keys := make([]string, 0, len(searchHandler.values))
+	for key := range searchHandler.values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	query := make(conditions, 0, len(keys))
+	values := make(url.Values)
+	errs := make([]error, 0)
+	for _, key := range keys {
+		name, operator, ok := parseFilterKey(key)
+		if !ok {
+			if searchHandler.strictFilters {
+				errs = append(errs, newUnknownFilterError(key))
+			}
+			continue
+		}
+
+		filter, ok := searchHandler.filters[name]
+		if !ok {
+			if searchHandler.strictFilters {
+				errs = append(errs, newUnknownFilterError(key))
+			}
+			continue
+		}
+
+		condition, ok := newCondition(filter, operator, searchHandler.values[key])
+		if !ok {
+			if searchHandler.strictFilters {
+				errs = append(errs, newInvalidParameterError(key, strings.Join(searchHandler.values[key], constValueSeparator), fmt.Sprintf("invalid value for the operator %s", operator)))
+			}
+			continue
+		}
+
+		if valueType, ok := searchHandler.filterTypes[name]; ok {
+			if value, ok := condition.parse(valueType); !ok {
+				errs = append(errs, newInvalidParameterError(key, value, valueType.reason))
+				continue
+			}
+		}
+
+		query = append(query, condition)
+		values[key] = searchHandler.values[key]
+	}
+
+	if len(errs) > 0 {
+		return nil, nil, errs
+	}
+
+	return query, values, nil
 }
 
 func newPagination(searchData *searchData, total int) *pagination {
-	pagination := pagination{}
-	totalPages := int(math.Ceil(float64(total) / float64(searchData.size)))
+	pagination := pagination{
+		Page:         searchData.page,
+		Size:         searchData.size,
+		IsTotalExact: searchData.isTotalExact,
+	}
+
+	totalPages := 0
+	if searchData.size > 0 {
+		totalPages = int(math.Ceil(float64(total) / float64(searchData.size)))
+	} else if total > 0 {
+		totalPages = 1
+	}
+
+	if searchData.hasTotal {
+		pagination.Total = &total
+		pagination.TotalPages = &totalPages
+	}
 
 	// if there are no results
-	if total == 0 {
+	if total == 0 || searchData.size <= 0 {
 		return &pagination
 	}
 
 	// first page
 	if totalPages > 1 && searchData.page > 1 {
-		first := fmt.Sprintf("%s?page=%d&size=%d", searchData.path, 1, searchData.size)
+		first := searchData.link(url.Values{constPage: {"1"}})
 		pagination.First = &first
 
 		// previous page
-		previous := fmt.Sprintf("%s?page=%d&size=%d", searchData.path, searchData.page-1, searchData.size)
+		previous := searchData.link(url.Values{constPage: {strconv.Itoa(searchData.page - 1)}})
 		pagination.Previous = &previous
 	}
 
 	// next page
 	if totalPages > searchData.page {
-		next := fmt.Sprintf("%s?page=%d&size=%d", searchData.path, searchData.page+1, searchData.size)
+		next := searchData.link(url.Values{constPage: {strconv.Itoa(searchData.page + 1)}})
 		pagination.Next = &next
 
 		// last page
-		size := searchData.size
+		last := searchData.link(url.Values{constPage: {strconv.Itoa(totalPages)}})
+		pagination.Last = &last
+	}
 
-		// calculate the remainder items on the last page
-		//remainder := total % searchData.size
-		//if remainder > 0 {
-		//	size = remainder
-		//}
+	return &pagination
+}
 
-		last := fmt.Sprintf("%s?page=%d&size=%d", searchData.path, totalPages, size)
-		pagination.Last = &last
+func newCursorPagination(searchData *searchData, total int) *pagination {
+	pagination := pagination{
+		Size:         searchData.size,
+		IsTotalExact: searchData.isTotalExact,
+	}
+
+	if searchData.hasTotal {
+		pagination.Total = &total
+	}
+
+	// previous page
+	if searchData.previousCursor != "" {
+		first := searchData.link(url.Values{})
+		pagination.First = &first
+
+		previous := searchData.link(url.Values{constCursor: {searchData.previousCursor}})
+		pagination.Previous = &previous
+		pagination.PreviousCursor = &searchData.previousCursor
+	}
+
+	// next page
+	if searchData.nextCursor != "" {
+		next := searchData.link(url.Values{constCursor: {searchData.nextCursor}})
+		pagination.Next = &next
+		pagination.NextCursor = &searchData.nextCursor
 	}
 
 	return &pagination
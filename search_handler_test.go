@@ -0,0 +1,251 @@
+package search
+
+import (
+	goerrors "errors"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestSize(t *testing.T) {
+	tests := []struct {
+		name    string
+		size    int
+		maxSize int
+		query   string
+		rows    int
+	}{
+		{name: "default size", size: 2, query: "", rows: 2},
+		{name: "size of the query", size: 2, query: "3", rows: 3},
+		{name: "size of 0 keeps the default size", size: 2, query: "0", rows: 2},
+		{name: "size above the max size", size: 2, maxSize: 3, query: "50", rows: 3},
+		{name: "size of 0 without a default size", maxSize: 3, query: "0", rows: 3},
+		{name: "without size nor max size", query: "", rows: 5},
+	}
+
+	db := newPersons(t)
+	for _, test := range tests {
+		items := make([]*testPerson, 0)
+		handler := (&Search{}).NewDatabaseSearch(db.Select("*").From("person")).
+			Size(test.size).
+			MaxSize(test.maxSize).
+			Bind(&items)
+		if test.query != "" {
+			handler.Query(map[string]string{constSize: test.query})
+		}
+
+		if _, errs := handler.Exec(); len(errs) > 0 {
+			t.Errorf("%s: %v", test.name, errs)
+			continue
+		}
+
+		if len(items) != test.rows {
+			t.Errorf("%s: %d rows, expected %d", test.name, len(items), test.rows)
+		}
+	}
+}
+
+// TestBackendName checks that the results report the name of the circuit breaker of the handler that answered,
+// shadowing only the results of the primary and not of its fallback on the same backend
+func TestBackendName(t *testing.T) {
+	tests := []struct {
+		name    string
+		err     error
+		backend string
+		shadow  bool
+	}{
+		{name: "primary answering", backend: "primary", shadow: true},
+		{name: "fallback answering", err: newBackendUnavailableError(constBackendDatabase, goerrors.New("connection refused")), backend: "replica", shadow: false},
+	}
+
+	db := newPersons(t)
+	for _, test := range tests {
+		search := &Search{}
+		shadowed := make(chan *ShadowDivergence, 1)
+
+		items := make([]*testPerson, 0)
+		result, errs := search.newSearchHandler(&testClient{name: constBackendDatabase, err: test.err}).
+			CircuitBreaker("primary").
+			Fallback(search.NewDatabaseSearch(db.Select("*").From("person")).CircuitBreaker("replica").Bind(&items)).
+			Shadow(search.newSearchHandler(&testClient{name: constBackendDatabase, err: goerrors.New("shadow")}).CircuitBreaker("shadow"), "id_person", func(divergence *ShadowDivergence) {
+				shadowed <- divergence
+			}).
+			Bind(&items).
+			Exec()
+		if len(errs) > 0 {
+			t.Fatalf("%s: %v", test.name, errs)
+		}
+
+		if result.Backend != test.backend {
+			t.Errorf("%s: backend %s, expected %s", test.name, result.Backend, test.backend)
+		}
+
+		select {
+		case divergence := <-shadowed:
+			if !test.shadow {
+				t.Errorf("%s: unexpected shadow search", test.name)
+			} else if divergence.Primary != "primary" || divergence.Secondary != "shadow" {
+				t.Errorf("%s: shadow of %s on %s", test.name, divergence.Primary, divergence.Secondary)
+			}
+		case <-time.After(100 * time.Millisecond):
+			if test.shadow {
+				t.Errorf("%s: expected a shadow search", test.name)
+			}
+		}
+	}
+}
+
+// TestQueryValues checks that the repeated query parameters of a request are joined with an OR
+func TestQueryValues(t *testing.T) {
+	tests := []struct {
+		query    string
+		expected []int
+	}{
+		{query: "first_name=joao&first_name=maria", expected: []int{1, 2}},
+		{query: "age=30&age=40", expected: []int{1, 3, 5}},
+		{query: "age[in]=25,40&age[in]=35", expected: []int{2, 3, 4}},
+		{query: "first_name[like]=jo&first_name[like]=ru", expected: []int{1, 3, 5}},
+		{query: "age=30&first_name=joao&first_name=rui&first_name=ana", expected: []int{1, 5}},
+		{query: "first_name=joao%20&first_name=maria", expected: []int{2}},
+	}
+
+	db := newPersons(t)
+	for _, test := range tests {
+		items := make([]*testPerson, 0)
+		_, errs := (&Search{}).NewDatabaseSearch(db.Select("*").From("person")).
+			Filters("first_name", "age").
+			Request(httptest.NewRequest("GET", "/persons?"+test.query, nil)).
+			OrderBy("id_person", orderAsc).
+			Bind(&items).
+			Exec()
+		if len(errs) > 0 {
+			t.Fatalf("%s: %v", test.query, errs)
+		}
+
+		ids := make([]int, len(items))
+		for i, item := range items {
+			ids[i] = item.IdPerson
+		}
+
+		if !reflect.DeepEqual(ids, test.expected) {
+			t.Errorf("%s: %v, expected %v", test.query, ids, test.expected)
+		}
+	}
+}
+
+// TestPaginationLinks checks that the links keep the filters, search and sort of the request
+func TestPaginationLinks(t *testing.T) {
+	db := newPersons(t)
+
+	items := make([]*testPerson, 0)
+	result, errs := (&Search{}).NewDatabaseSearch(db.Select("*").From("person")).
+		Filters("age").
+		SearchFilters("first_name", "last_name").
+		Sortable("id", "id_person").
+		Request(httptest.NewRequest("GET", "/persons?age[gte]=30&search=o&sort=-id&size=1&page=2", nil)).
+		Bind(&items).
+		Exec()
+	if len(errs) > 0 {
+		t.Fatal(errs)
+	}
+
+	// rui, ana, jose and joao with an o and at least 30 years, sorted by the id descending
+	if len(items) != 1 || items[0].IdPerson != 4 {
+		t.Fatalf("items %+v, expected ana", items)
+	}
+
+	links := map[string]*string{
+		"first":    result.Pagination.First,
+		"previous": result.Pagination.Previous,
+		"next":     result.Pagination.Next,
+		"last":     result.Pagination.Last,
+	}
+	expected := map[string]string{
+		"first":    "/persons?age%5Bgte%5D=30&page=1&search=o&size=1&sort=-id",
+		"previous": "/persons?age%5Bgte%5D=30&page=1&search=o&size=1&sort=-id",
+		"next":     "/persons?age%5Bgte%5D=30&page=3&search=o&size=1&sort=-id",
+		"last":     "/persons?age%5Bgte%5D=30&page=4&search=o&size=1&sort=-id",
+	}
+
+	for name, link := range links {
+		if link == nil || *link != expected[name] {
+			t.Errorf("%s link %v, expected %s", name, link, expected[name])
+		}
+	}
+}
+
+func TestPaginationTotals(t *testing.T) {
+	tests := []struct {
+		name       string
+		searchData *searchData
+		total      int
+		totalPages *int
+		next       bool
+		previous   bool
+	}{
+		{name: "first of 3 pages", searchData: &searchData{hasTotal: true, isTotalExact: true, page: 1, size: 2}, total: 5, totalPages: intPointer(3), next: true},
+		{name: "middle page", searchData: &searchData{hasTotal: true, isTotalExact: true, page: 2, size: 2}, total: 5, totalPages: intPointer(3), next: true, previous: true},
+		{name: "last page", searchData: &searchData{hasTotal: true, isTotalExact: true, page: 3, size: 2}, total: 6, totalPages: intPointer(3), previous: true},
+		{name: "without results", searchData: &searchData{hasTotal: true, isTotalExact: true, page: 1, size: 2}, total: 0, totalPages: intPointer(0)},
+		{name: "without size", searchData: &searchData{hasTotal: true, isTotalExact: true, page: 1}, total: 5, totalPages: intPointer(1)},
+		{name: "without total", searchData: &searchData{page: 1, size: 2}, total: 0},
+	}
+
+	for _, test := range tests {
+		pagination := newPagination(test.searchData, test.total)
+
+		if pagination.Page != test.searchData.page || pagination.Size != test.searchData.size || pagination.IsTotalExact != test.searchData.isTotalExact {
+			t.Errorf("%s: page %d size %d exact %t", test.name, pagination.Page, pagination.Size, pagination.IsTotalExact)
+		}
+
+		if !reflect.DeepEqual(pagination.TotalPages, test.totalPages) {
+			t.Errorf("%s: total pages %v, expected %v", test.name, pagination.TotalPages, test.totalPages)
+		}
+
+		if test.searchData.hasTotal != (pagination.Total != nil) || (pagination.Total != nil && *pagination.Total != test.total) {
+			t.Errorf("%s: total %v, expected %d", test.name, pagination.Total, test.total)
+		}
+
+		if (pagination.Next != nil) != test.next || (pagination.Previous != nil) != test.previous {
+			t.Errorf("%s: next %v previous %v, expected %t and %t", test.name, pagination.Next, pagination.Previous, test.next, test.previous)
+		}
+	}
+}
+
+// TestResultTotals checks the totals of the offset and cursor pagination of the database
+func TestResultTotals(t *testing.T) {
+	db := newPersons(t)
+
+	items := make([]*testPerson, 0)
+	result, errs := (&Search{}).NewDatabaseSearch(db.Select("*").From("person")).
+		Query(map[string]string{constPage: "2", constSize: "2"}).
+		Bind(&items).
+		Exec()
+	if len(errs) > 0 {
+		t.Fatal(errs)
+	}
+
+	if pagination := result.Pagination; pagination.Total == nil || *pagination.Total != 5 || *pagination.TotalPages != 3 || pagination.Page != 2 || pagination.Size != 2 || !pagination.IsTotalExact {
+		t.Errorf("offset pagination %+v", pagination)
+	}
+
+	result, errs = (&Search{}).NewDatabaseSearch(db.Select("*").From("person")).
+		WithCursorPagination().
+		Tiebreaker("id_person").
+		Query(map[string]string{constSize: "2"}).
+		Bind(&items).
+		Exec()
+	if len(errs) > 0 {
+		t.Fatal(errs)
+	}
+
+	// the cursor pagination doesn't count the rows
+	if pagination := result.Pagination; pagination.Total != nil || pagination.TotalPages != nil || pagination.Size != 2 || pagination.NextCursor == nil {
+		t.Errorf("cursor pagination %+v", pagination)
+	}
+}
+
+func intPointer(value int) *int {
+	return &value
+}
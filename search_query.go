@@ -0,0 +1,139 @@
+package search
+
+import (
+	"strings"
+	"unicode"
+)
+
+const (
+	constSearchOr       = "OR"
+	constSearchQuote    = '"'
+	constSearchRequired = '+'
+	constSearchExcluded = '-'
+	constSearchScope    = ':'
+)
+
+// searchTerm is a word or a quoted phrase of the search, on all the search fields or on the scoped one
+type searchTerm struct {
+	value    string
+	field    string
+	phrase   bool
+	excluded bool
+}
+
+// searchQuery is the parsed search, with groups joined with OR of terms joined with AND
+type searchQuery [][]*searchTerm
+
+// parseSearch parses a search like `"exact phrase" +must -exclude name:joao OR other`, where the
+// field scopes are only accepted on the search fields, by column or by its name without the table
+func parseSearch(search string, searchFilters []string) searchQuery {
+	fields := make(map[string]string)
+	for _, filter := range searchFilters {
+		fields[filter] = filter
+		if index := strings.LastIndex(filter, "."); index > -1 {
+			fields[filter[index+1:]] = filter
+		}
+	}
+
+	query := make(searchQuery, 0)
+	group := make([]*searchTerm, 0)
+	runes := []rune(search)
+
+	for i := 0; i < len(runes); {
+		if unicode.IsSpace(runes[i]) {
+			i++
+			continue
+		}
+
+		term := &searchTerm{}
+		start := i
+
+		// required or excluded
+		if (runes[i] == constSearchRequired || runes[i] == constSearchExcluded) && i+1 < len(runes) && !unicode.IsSpace(runes[i+1]) {
+			term.excluded = runes[i] == constSearchExcluded
+			i++
+		}
+
+		// field scope
+		if end := scopeEnd(runes, i); end > i {
+			if field, ok := fields[string(runes[i:end])]; ok {
+				term.field = field
+				i = end + 1
+			}
+		}
+
+		// phrase or word
+		if i < len(runes) && runes[i] == constSearchQuote {
+			end := i + 1
+			for end < len(runes) && runes[end] != constSearchQuote {
+				end++
+			}
+			term.value = strings.TrimSpace(string(runes[i+1 : end]))
+			term.phrase = true
+			i = end + 1
+		} else {
+			end := i
+			for end < len(runes) && !unicode.IsSpace(runes[end]) {
+				end++
+			}
+			term.value = string(runes[i:end])
+			i = end
+
+			if term.value == constSearchOr && i-start == len(constSearchOr) {
+				if len(group) > 0 {
+					query = append(query, group)
+					group = make([]*searchTerm, 0)
+				}
+				continue
+			}
+		}
+
+		if term.value != "" {
+			group = append(group, term)
+		}
+	}
+
+	if len(group) > 0 {
+		query = append(query, group)
+	}
+
+	return query
+}
+
+// scopeEnd returns the position of the colon ending a field scope, or the start when there isn't one
+func scopeEnd(runes []rune, start int) int {
+	for i := start; i < len(runes)-1; i++ {
+		switch {
+		case runes[i] == constSearchScope:
+			if i > start && !unicode.IsSpace(runes[i+1]) {
+				return i
+			}
+			return start
+		case !(unicode.IsLetter(runes[i]) || unicode.IsDigit(runes[i]) || runes[i] == '_' || runes[i] == '.'):
+			return start
+		}
+	}
+
+	return start
+}
+
+// fields returns the scoped field of the term or all the search fields
+func (term *searchTerm) fields(searchFilters []string) []string {
+	if term.field != "" {
+		return []string{term.field}
+	}
+	return searchFilters
+}
+
+// included returns the terms that aren't excluded
+func (query searchQuery) included() []*searchTerm {
+	terms := make([]*searchTerm, 0)
+	for _, group := range query {
+		for _, term := range group {
+			if !term.excluded {
+				terms = append(terms, term)
+			}
+		}
+	}
+	return terms
+}
@@ -0,0 +1,60 @@
+package search
+
+import (
+	"strings"
+	"testing"
+)
+
+// String formats the parsed search back on the search syntax, with the scoped fields by column
+func (query searchQuery) String() string {
+	groups := make([]string, len(query))
+	for i, group := range query {
+		terms := make([]string, len(group))
+		for j, term := range group {
+			value := term.value
+			if term.phrase {
+				value = `"` + value + `"`
+			}
+			if term.field != "" {
+				value = term.field + ":" + value
+			}
+			if term.excluded {
+				value = "-" + value
+			}
+			terms[j] = value
+		}
+		groups[i] = strings.Join(terms, " ")
+	}
+
+	return strings.Join(groups, " OR ")
+}
+
+func TestParseSearch(t *testing.T) {
+	tests := []struct {
+		search   string
+		expected string
+	}{
+		{search: "joao ribeiro", expected: "joao ribeiro"},
+		{search: `"joao ribeiro" -test`, expected: `"joao ribeiro" -test`},
+		{search: "+joao -ribeiro", expected: "joao -ribeiro"},
+		{search: `-"exact phrase"`, expected: `-"exact phrase"`},
+		{search: "joao OR maria silva", expected: "joao OR maria silva"},
+		{search: "OR joao OR OR", expected: "joao"},
+		{search: "ORacle or", expected: "ORacle or"},
+		{search: "first_name:maria last_name:silva", expected: "person.first_name:maria last_name:silva"},
+		{search: `-first_name:"maria silva"`, expected: `-person.first_name:"maria silva"`},
+		{search: "age:30", expected: "age:30"},
+		{search: "first_name: maria", expected: "first_name: maria"},
+		{search: `"unbalanced quote`, expected: `"unbalanced quote"`},
+		{search: `joao "unbalanced -quote`, expected: `joao "unbalanced -quote"`},
+		{search: `"" joao`, expected: "joao"},
+		{search: `" "`, expected: ""},
+		{search: "  ", expected: ""},
+	}
+
+	for _, test := range tests {
+		if query := parseSearch(test.search, []string{"person.first_name", "last_name"}); query.String() != test.expected {
+			t.Errorf("search %s: parsed %s, expected %s", test.search, query, test.expected)
+		}
+	}
+}
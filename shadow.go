@@ -0,0 +1,226 @@
+package search
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"reflect"
+	"time"
+
+	"github.com/joaosoft/logger"
+)
+
+const (
+	constDefaultShadowLimit   = 10
+	constDefaultShadowTimeout = 10 * time.Second
+)
+
+// ShadowDivergence describes the differences between the primary and the shadow search results
+type ShadowDivergence struct {
+	Primary        string        `json:"primary"`
+	Secondary      string        `json:"secondary"`
+	Missing        []interface{} `json:"missing,omitempty"`
+	Extra          []interface{} `json:"extra,omitempty"`
+	OrderMismatch  bool          `json:"order_mismatch"`
+	PrimaryTotal   *int          `json:"primary_total,omitempty"`
+	SecondaryTotal *int          `json:"secondary_total,omitempty"`
+	Errors         []error       `json:"-"`
+}
+
+// ShadowReporter receives the divergences found by the shadow search
+type ShadowReporter func(divergence *ShadowDivergence)
+
+type shadow struct {
+	handler    *searchHandler
+	idField    string
+	reporter   ShadowReporter
+	sampleRate float64
+	slots      chan struct{}
+	timeout    time.Duration
+	logger     logger.ILogger
+}
+
+// Shadow runs a secondary search asynchronously after the primary one answers, comparing the ids,
+// ordering and totals of both results and reporting the divergences without changing the response;
+// the searches are sampled and limited by WithShadow and time out with the secondary or primary timeout
+func (searchHandler *searchHandler) Shadow(secondary *searchHandler, idField string, reporter ...ShadowReporter) *searchHandler {
+	shadow := &shadow{
+		handler:    secondary,
+		idField:    idField,
+		reporter:   searchHandler.logDivergence,
+		sampleRate: searchHandler.shadowRate,
+		slots:      searchHandler.shadowSlots,
+		timeout:    searchHandler.timeout,
+		logger:     searchHandler.logger,
+	}
+	if len(reporter) > 0 {
+		shadow.reporter = reporter[0]
+	}
+
+	searchHandler.shadow = shadow
+	return searchHandler
+}
+
+func (shadow *shadow) run(primary *searchResult) {
+	if shadow.sampleRate > 0 && shadow.sampleRate < 1 && rand.Float64() >= shadow.sampleRate {
+		return
+	}
+
+	// skipped when the limit of shadow searches is running
+	select {
+	case shadow.slots <- struct{}{}:
+	default:
+		return
+	}
+
+	// read the primary ids now, the result is handed over to the caller
+	primaryIds := resultIds(primary.Result, shadow.idField)
+	primaryTotal := resultTotal(primary)
+
+	go func() {
+		defer func() {
+			<-shadow.slots
+
+			if err := recover(); err != nil && shadow.logger != nil {
+				shadow.logger.Errorf("shadow search on %s panicked: %v", shadow.handler.name, err)
+			}
+		}()
+
+		divergence := &ShadowDivergence{
+			Primary:      primary.Backend,
+			Secondary:    shadow.handler.name,
+			PrimaryTotal: primaryTotal,
+		}
+
+		timeout := shadow.handler.timeout
+		if timeout == 0 {
+			timeout = shadow.timeout
+		}
+		if timeout == 0 {
+			timeout = constDefaultShadowTimeout
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), timeout)
+		defer cancel()
+
+		secondary, errs := shadow.handler.ExecContext(ctx)
+		if len(errs) > 0 {
+			divergence.Errors = errs
+			shadow.reporter(divergence)
+			return
+		}
+
+		divergence.Secondary = secondary.Backend
+		divergence.SecondaryTotal = resultTotal(secondary)
+
+		if divergence.compare(primaryIds, resultIds(secondary.Result, shadow.idField)) {
+			shadow.reporter(divergence)
+		}
+	}()
+}
+
+// shadows returns the slots of the shadow searches running at the same time, shared by all the searches
+func (search *Search) shadows() chan struct{} {
+	search.mux.Lock()
+	defer search.mux.Unlock()
+
+	if search.shadowSlots == nil {
+		limit := search.shadowLimit
+		if limit <= 0 {
+			limit = constDefaultShadowLimit
+		}
+		search.shadowSlots = make(chan struct{}, limit)
+	}
+
+	return search.shadowSlots
+}
+
+// compare fills the divergence with the differences of the ids and totals, returning if there are any
+func (divergence *ShadowDivergence) compare(primaryIds []interface{}, secondaryIds []interface{}) bool {
+	primaryKeys := make(map[string]bool)
+	for _, id := range primaryIds {
+		primaryKeys[fmt.Sprint(id)] = true
+	}
+
+	secondaryKeys := make(map[string]bool)
+	for _, id := range secondaryIds {
+		secondaryKeys[fmt.Sprint(id)] = true
+	}
+
+	primaryCommon := make([]string, 0)
+	for _, id := range primaryIds {
+		if !secondaryKeys[fmt.Sprint(id)] {
+			divergence.Missing = append(divergence.Missing, id)
+			continue
+		}
+		primaryCommon = append(primaryCommon, fmt.Sprint(id))
+	}
+
+	secondaryCommon := make([]string, 0)
+	for _, id := range secondaryIds {
+		if !primaryKeys[fmt.Sprint(id)] {
+			divergence.Extra = append(divergence.Extra, id)
+			continue
+		}
+		secondaryCommon = append(secondaryCommon, fmt.Sprint(id))
+	}
+
+	divergence.OrderMismatch = !reflect.DeepEqual(primaryCommon, secondaryCommon)
+
+	totalMismatch := (divergence.PrimaryTotal == nil) != (divergence.SecondaryTotal == nil) ||
+		(divergence.PrimaryTotal != nil && *divergence.PrimaryTotal != *divergence.SecondaryTotal)
+
+	return len(divergence.Missing) > 0 || len(divergence.Extra) > 0 || divergence.OrderMismatch || totalMismatch
+}
+
+func (searchHandler *searchHandler) logDivergence(divergence *ShadowDivergence) {
+	if len(divergence.Errors) > 0 {
+		searchHandler.logger.Warnf("shadow search on %s failed: %v", divergence.Secondary, divergence.Errors)
+		return
+	}
+
+	searchHandler.logger.Warnf("shadow search on %s diverges from %s: missing %v, extra %v, order mismatch %t",
+		divergence.Secondary, divergence.Primary, divergence.Missing, divergence.Extra, divergence.OrderMismatch)
+}
+
+func resultIds(object interface{}, idField string) []interface{} {
+	ids := make([]interface{}, 0)
+
+	value := reflect.ValueOf(object)
+	for value.Kind() == reflect.Ptr || value.Kind() == reflect.Interface {
+		if value.IsNil() {
+			return ids
+		}
+		value = value.Elem()
+	}
+
+	if value.Kind() != reflect.Slice {
+		return ids
+	}
+
+	for i := 0; i < value.Len(); i++ {
+		row := value.Index(i)
+		for row.Kind() == reflect.Ptr || row.Kind() == reflect.Interface {
+			if row.IsNil() {
+				break
+			}
+			row = row.Elem()
+		}
+
+		for _, tag := range []string{constTagDatabase, constTagElastic} {
+			if id, ok := fieldByTag(row, tag, idField); ok {
+				ids = append(ids, id)
+				break
+			}
+		}
+	}
+
+	return ids
+}
+
+func resultTotal(result *searchResult) *int {
+	if result.Pagination == nil {
+		return nil
+	}
+	return result.Pagination.Total
+}
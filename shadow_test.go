@@ -0,0 +1,109 @@
+package search
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// panicClient panics on the search, as a bug on the secondary backend
+type panicClient struct{}
+
+func (client *panicClient) Exec(ctx context.Context, searchData *searchData) (int, error) {
+	panic("secondary search")
+}
+
+func (client *panicClient) backend() string {
+	return constBackendElastic
+}
+
+func TestShadowDivergence(t *testing.T) {
+	total, otherTotal := 3, 4
+
+	tests := []struct {
+		name           string
+		primary        []interface{}
+		secondary      []interface{}
+		primaryTotal   *int
+		secondaryTotal *int
+		missing        []interface{}
+		extra          []interface{}
+		orderMismatch  bool
+		diverges       bool
+	}{
+		{name: "same results", primary: []interface{}{1, 2, 3}, secondary: []interface{}{1, 2, 3}, primaryTotal: &total, secondaryTotal: &total},
+		{name: "missing on the secondary", primary: []interface{}{1, 2, 3}, secondary: []interface{}{1, 3}, missing: []interface{}{2}, diverges: true},
+		{name: "extra on the secondary", primary: []interface{}{1, 2}, secondary: []interface{}{1, 2, 4}, extra: []interface{}{4}, diverges: true},
+		{name: "other order", primary: []interface{}{1, 2, 3}, secondary: []interface{}{1, 3, 2}, orderMismatch: true, diverges: true},
+		{name: "other order of the common ids", primary: []interface{}{1, 2, 3}, secondary: []interface{}{3, 4, 1}, missing: []interface{}{2}, extra: []interface{}{4}, orderMismatch: true, diverges: true},
+		{name: "ids of other types", primary: []interface{}{1, 2}, secondary: []interface{}{"1", "2"}},
+		{name: "other total", primary: []interface{}{1}, secondary: []interface{}{1}, primaryTotal: &total, secondaryTotal: &otherTotal, diverges: true},
+		{name: "total only on the primary", primary: []interface{}{1}, secondary: []interface{}{1}, primaryTotal: &total, diverges: true},
+	}
+
+	for _, test := range tests {
+		divergence := &ShadowDivergence{PrimaryTotal: test.primaryTotal, SecondaryTotal: test.secondaryTotal}
+		if diverges := divergence.compare(test.primary, test.secondary); diverges != test.diverges {
+			t.Errorf("%s: diverges %t, expected %t", test.name, diverges, test.diverges)
+		}
+
+		if !reflect.DeepEqual(divergence.Missing, test.missing) || !reflect.DeepEqual(divergence.Extra, test.extra) || divergence.OrderMismatch != test.orderMismatch {
+			t.Errorf("%s: missing %v extra %v order mismatch %t, expected %v %v %t", test.name,
+				divergence.Missing, divergence.Extra, divergence.OrderMismatch, test.missing, test.extra, test.orderMismatch)
+		}
+	}
+}
+
+// TestShadowRun checks that the shadow searches are sampled, skipped above the limit and recovered from panics
+func TestShadowRun(t *testing.T) {
+	primary := &searchResult{Backend: constBackendDatabase, Result: &[]*testPerson{{IdPerson: 1}}}
+
+	// sampling
+	search := &Search{}
+	search.Reconfigure(WithShadow(1e-9, 1))
+	handler := search.newSearchHandler(&testClient{name: constBackendDatabase})
+	handler.Shadow(search.newSearchHandler(&panicClient{}), "id_person")
+	handler.shadow.run(primary)
+	if len(handler.shadowSlots) != 0 {
+		t.Error("sampling: the shadow search wasn't skipped")
+	}
+
+	// limit
+	search = &Search{}
+	search.Reconfigure(WithShadow(1, 1))
+	reported := make(chan *ShadowDivergence, 2)
+	slow := search.newSearchHandler(&slowClient{delay: 100 * time.Millisecond, persons: []*testPerson{{IdPerson: 2}}})
+	handler = search.newSearchHandler(&testClient{name: constBackendDatabase}).
+		Shadow(slow.Bind(&[]*testPerson{}), "id_person", func(divergence *ShadowDivergence) { reported <- divergence })
+	handler.shadow.run(primary)
+	handler.shadow.run(primary)
+
+	select {
+	case divergence := <-reported:
+		if !reflect.DeepEqual(divergence.Extra, []interface{}{2}) {
+			t.Errorf("limit: divergence %+v", divergence)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("limit: the shadow search didn't run")
+	}
+
+	select {
+	case <-reported:
+		t.Error("limit: the shadow search above the limit wasn't skipped")
+	case <-time.After(200 * time.Millisecond):
+	}
+
+	// panic
+	handler = search.newSearchHandler(&testClient{name: constBackendDatabase}).
+		Shadow(search.newSearchHandler(&panicClient{}), "id_person")
+	handler.shadow.run(primary)
+
+	for i := 0; i < 100 && len(handler.shadowSlots) > 0; i++ {
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	if len(handler.shadowSlots) != 0 {
+		t.Error("panic: the slot of the shadow search wasn't released")
+	}
+}
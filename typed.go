@@ -0,0 +1,234 @@
+package search
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/joaosoft/dbr"
+	"github.com/joaosoft/elastic"
+)
+
+// Result is the typed result of a search
+type Result[T any] struct {
+	Backend    string                 `json:"backend,omitempty"`
+	Items      []T                    `json:"result"`
+	Metadata   map[string]interface{} `json:"Metadata,omitempty"`
+	Pagination *pagination            `json:"pagination,omitempty"`
+}
+
+// TypedMetadataFunction is a metadata function receiving the typed items of the search
+type TypedMetadataFunction[T any] func(items []T, object interface{}, metadata map[string]*Metadata) error
+
+// TypedSearch is a search bound to a slice of T, with the builder methods of the search handler returning
+// the typed search, except Bind since the typed search is bound to its own items
+type TypedSearch[T any] struct {
+	handler *searchHandler
+	items   []T
+}
+
+// Database creates a typed search on the database, registering the filters, search fields and sortables tagged on T
+func Database[T any](search *Search, stmt *dbr.StmtSelect) *TypedSearch[T] {
+	return newTypedSearch[T](search.NewDatabaseSearch(stmt))
+}
+
+// Elastic creates a typed search on elastic, registering the filters, search fields and sortables tagged on T
+func Elastic[T any](search *Search, stmt *elastic.SearchService) *TypedSearch[T] {
+	return newTypedSearch[T](search.NewElasticSearch(stmt))
+}
+
+func newTypedSearch[T any](handler *searchHandler) *TypedSearch[T] {
+	typed := &TypedSearch[T]{handler: handler, items: make([]T, 0)}
+	handler.Bind(&typed.items).FromModel(typed.items)
+	return typed
+}
+
+// Handler returns the underlying search handler, to be used as a fallback or shadow of other searches
+func (typed *TypedSearch[T]) Handler() *searchHandler {
+	return typed.handler
+}
+
+func (typed *TypedSearch[T]) Query(query map[string]string) *TypedSearch[T] {
+	typed.handler.Query(query)
+	return typed
+}
+
+func (typed *TypedSearch[T]) QueryValues(values url.Values) *TypedSearch[T] {
+	typed.handler.QueryValues(values)
+	return typed
+}
+
+func (typed *TypedSearch[T]) Request(request *http.Request) *TypedSearch[T] {
+	typed.handler.Request(request)
+	return typed
+}
+
+func (typed *TypedSearch[T]) Filters(fields ...string) *TypedSearch[T] {
+	typed.handler.Filters(fields...)
+	return typed
+}
+
+func (typed *TypedSearch[T]) Filter(searchName string, internalName string) *TypedSearch[T] {
+	typed.handler.Filter(searchName, internalName)
+	return typed
+}
+
+func (typed *TypedSearch[T]) FilterType(searchName string, valueType *ValueType) *TypedSearch[T] {
+	typed.handler.FilterType(searchName, valueType)
+	return typed
+}
+
+func (typed *TypedSearch[T]) StrictFilters() *TypedSearch[T] {
+	typed.handler.StrictFilters()
+	return typed
+}
+
+func (typed *TypedSearch[T]) SearchFilters(fields ...string) *TypedSearch[T] {
+	typed.handler.SearchFilters(fields...)
+	return typed
+}
+
+func (typed *TypedSearch[T]) SearchField(field string, boost float64, match match) *TypedSearch[T] {
+	typed.handler.SearchField(field, boost, match)
+	return typed
+}
+
+func (typed *TypedSearch[T]) FuzzySearch(threshold ...float64) *TypedSearch[T] {
+	typed.handler.FuzzySearch(threshold...)
+	return typed
+}
+
+func (typed *TypedSearch[T]) FullTextSearch(config string, vectorColumn ...string) *TypedSearch[T] {
+	typed.handler.FullTextSearch(config, vectorColumn...)
+	return typed
+}
+
+func (typed *TypedSearch[T]) Sortable(searchName string, internalName string) *TypedSearch[T] {
+	typed.handler.Sortable(searchName, internalName)
+	return typed
+}
+
+func (typed *TypedSearch[T]) WithoutPagination() *TypedSearch[T] {
+	typed.handler.WithoutPagination()
+	return typed
+}
+
+func (typed *TypedSearch[T]) WithCursorPagination() *TypedSearch[T] {
+	typed.handler.WithCursorPagination()
+	return typed
+}
+
+func (typed *TypedSearch[T]) Tiebreaker(field string) *TypedSearch[T] {
+	typed.handler.Tiebreaker(field)
+	return typed
+}
+
+func (typed *TypedSearch[T]) WithoutMetadata() *TypedSearch[T] {
+	typed.handler.WithoutMetadata()
+	return typed
+}
+
+func (typed *TypedSearch[T]) Metadata(name string, stmt interface{}, object interface{}) *TypedSearch[T] {
+	typed.handler.Metadata(name, stmt, object)
+	return typed
+}
+
+func (typed *TypedSearch[T]) OrderBy(field string, direction direction) *TypedSearch[T] {
+	typed.handler.OrderBy(field, direction)
+	return typed
+}
+
+func (typed *TypedSearch[T]) Search(value string) *TypedSearch[T] {
+	typed.handler.Search(value)
+	return typed
+}
+
+func (typed *TypedSearch[T]) Cursor(cursor string) *TypedSearch[T] {
+	typed.handler.Cursor(cursor)
+	return typed
+}
+
+func (typed *TypedSearch[T]) Page(page int) *TypedSearch[T] {
+	typed.handler.Page(page)
+	return typed
+}
+
+func (typed *TypedSearch[T]) MaxSize(maxSize int) *TypedSearch[T] {
+	typed.handler.MaxSize(maxSize)
+	return typed
+}
+
+func (typed *TypedSearch[T]) Path(path string) *TypedSearch[T] {
+	typed.handler.Path(path)
+	return typed
+}
+
+func (typed *TypedSearch[T]) Size(size int) *TypedSearch[T] {
+	typed.handler.Size(size)
+	return typed
+}
+
+func (typed *TypedSearch[T]) Fallback(fallback fallback, policy ...FallbackPolicy) *TypedSearch[T] {
+	typed.handler.Fallback(fallback, policy...)
+	return typed
+}
+
+func (typed *TypedSearch[T]) Shadow(secondary *searchHandler, idField string, reporter ...ShadowReporter) *TypedSearch[T] {
+	typed.handler.Shadow(secondary, idField, reporter...)
+	return typed
+}
+
+func (typed *TypedSearch[T]) Hedge(delay time.Duration) *TypedSearch[T] {
+	typed.handler.Hedge(delay)
+	return typed
+}
+
+func (typed *TypedSearch[T]) CircuitBreaker(name string) *TypedSearch[T] {
+	typed.handler.CircuitBreaker(name)
+	return typed
+}
+
+func (typed *TypedSearch[T]) Timeout(timeout time.Duration) *TypedSearch[T] {
+	typed.handler.Timeout(timeout)
+	return typed
+}
+
+func (typed *TypedSearch[T]) MetadataFunction(name string, function TypedMetadataFunction[T], object interface{}) *TypedSearch[T] {
+	typed.handler.MetadataFunction(name, func(result interface{}, object interface{}, metadata map[string]*Metadata) error {
+		items, _ := result.([]T)
+		return function(items, object, metadata)
+	}, object)
+	return typed
+}
+
+func (typed *TypedSearch[T]) Exec() (*Result[T], []error) {
+	return typed.ExecContext(context.Background())
+}
+
+func (typed *TypedSearch[T]) ExecContext(ctx context.Context) (*Result[T], []error) {
+	result, errs := typed.handler.ExecContext(ctx)
+	if len(errs) > 0 {
+		return nil, errs
+	}
+
+	typedResult := &Result[T]{
+		Backend:    result.Backend,
+		Items:      make([]T, 0),
+		Pagination: result.Pagination,
+	}
+
+	// the result may come from a fallback bound to its own object
+	switch items := result.Result.(type) {
+	case *[]T:
+		typedResult.Items = *items
+	case []T:
+		typedResult.Items = items
+	}
+
+	if metadata, ok := result.Metadata.(map[string]interface{}); ok {
+		typedResult.Metadata = metadata
+	}
+
+	return typedResult, nil
+}
@@ -0,0 +1,78 @@
+package search
+
+import (
+	"encoding/json"
+	"reflect"
+	"sort"
+	"testing"
+)
+
+func TestTypedSearch(t *testing.T) {
+	db := newPersons(t)
+	search := &Search{}
+
+	typed, errs := Database[testPerson](search, db.Select("*").From("person")).
+		Query(map[string]string{"age": "30", "sort": "id_person"}).
+		MetadataFunction("count", func(items []testPerson, object interface{}, metadata map[string]*Metadata) error {
+			*object.(*int) = len(items)
+			return nil
+		}, new(int)).
+		Page(1).
+		Size(1).
+		Exec()
+	if len(errs) > 0 {
+		t.Fatal(errs)
+	}
+
+	if len(typed.Items) != 1 || typed.Items[0].FirstName != "joao" {
+		t.Errorf("items %+v, expected joao", typed.Items)
+	}
+
+	if typed.Pagination == nil || typed.Pagination.Total == nil || *typed.Pagination.Total != 2 || typed.Pagination.Next == nil {
+		t.Errorf("pagination %+v, expected a total of 2 with a next page", typed.Pagination)
+	}
+
+	items := make([]*testPerson, 0)
+	untyped, errs := search.NewDatabaseSearch(db.Select("*").From("person")).
+		Query(map[string]string{"age": "30", "sort": "id_person"}).
+		Filters("age").
+		Sortable("id_person", "id_person").
+		MetadataFunction("count", func(result interface{}, object interface{}, metadata map[string]*Metadata) error {
+			*object.(*int) = len(result.([]*testPerson))
+			return nil
+		}, new(int)).
+		Page(1).
+		Size(1).
+		Bind(&items).
+		Exec()
+	if len(errs) > 0 {
+		t.Fatal(errs)
+	}
+
+	// the typed result is encoded with the keys of the untyped one
+	if typedKeys, untypedKeys := jsonKeys(t, typed), jsonKeys(t, untyped); !reflect.DeepEqual(typedKeys, untypedKeys) {
+		t.Errorf("typed keys %v, expected %v", typedKeys, untypedKeys)
+	}
+}
+
+func jsonKeys(t *testing.T, value interface{}) []string {
+	t.Helper()
+
+	body, err := json.Marshal(value)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	object := make(map[string]interface{})
+	if err := json.Unmarshal(body, &object); err != nil {
+		t.Fatal(err)
+	}
+
+	keys := make([]string, 0, len(object))
+	for key := range object {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	return keys
+}
@@ -0,0 +1,119 @@
+package search
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	constDateFormat = "2006-01-02"
+)
+
+var uuidRegex = regexp.MustCompile(`^[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}$`)
+
+// ValueType parses and validates the values of a filter before the search is executed
+type ValueType struct {
+	name   string
+	reason string
+	parse  func(value string) (interface{}, bool)
+}
+
+var (
+	TypeString = &ValueType{name: "string", reason: "must be a string", parse: func(value string) (interface{}, bool) {
+		return value, true
+	}}
+	TypeInt = &ValueType{name: "int", reason: "must be an integer", parse: func(value string) (interface{}, bool) {
+		parsed, err := strconv.ParseInt(value, 10, 64)
+		return parsed, err == nil
+	}}
+	TypeUint = &ValueType{name: "uint", reason: "must be a non-negative integer", parse: func(value string) (interface{}, bool) {
+		parsed, err := strconv.ParseUint(value, 10, 64)
+		return parsed, err == nil
+	}}
+	TypeFloat = &ValueType{name: "float", reason: "must be a number", parse: func(value string) (interface{}, bool) {
+		parsed, err := strconv.ParseFloat(value, 64)
+		return parsed, err == nil
+	}}
+	TypeBool = &ValueType{name: "bool", reason: "must be a boolean", parse: func(value string) (interface{}, bool) {
+		parsed, err := strconv.ParseBool(value)
+		return parsed, err == nil
+	}}
+	TypeDate = &ValueType{name: "date", reason: "must be a date like 2006-01-02", parse: func(value string) (interface{}, bool) {
+		parsed, err := time.Parse(constDateFormat, value)
+		return parsed, err == nil
+	}}
+	TypeTime = &ValueType{name: "time", reason: "must be a RFC 3339 time like 2006-01-02T15:04:05Z", parse: func(value string) (interface{}, bool) {
+		parsed, err := time.Parse(time.RFC3339, value)
+		return parsed, err == nil
+	}}
+	TypeUUID = &ValueType{name: "uuid", reason: "must be an uuid", parse: func(value string) (interface{}, bool) {
+		return strings.ToLower(value), uuidRegex.MatchString(value)
+	}}
+
+	valueTypes = map[string]*ValueType{
+		TypeString.name: TypeString,
+		TypeInt.name:    TypeInt,
+		TypeUint.name:   TypeUint,
+		TypeFloat.name:  TypeFloat,
+		TypeBool.name:   TypeBool,
+		TypeDate.name:   TypeDate,
+		TypeTime.name:   TypeTime,
+		TypeUUID.name:   TypeUUID,
+	}
+)
+
+// TypeEnum accepts only the given values
+func TypeEnum(values ...string) *ValueType {
+	allowed := make(map[string]bool, len(values))
+	for _, value := range values {
+		allowed[value] = true
+	}
+
+	return &ValueType{
+		name:   "enum",
+		reason: fmt.Sprintf("must be one of %s", strings.Join(values, ", ")),
+		parse: func(value string) (interface{}, bool) {
+			return value, allowed[value]
+		},
+	}
+}
+
+// valueTypeByName returns the value type with the name used on the configuration
+func valueTypeByName(name string, values []string) (*ValueType, bool) {
+	if name == "enum" {
+		return TypeEnum(values...), true
+	}
+
+	valueType, ok := valueTypes[name]
+	return valueType, ok
+}
+
+// parse converts the values of the condition with the value type, the like and isnull
+// operators keep their values since they are matched as text and as a boolean
+func (condition *condition) parse(valueType *ValueType) (string, bool) {
+	switch condition.operator {
+	case operatorLike, operatorIsNull:
+		return "", true
+	case operatorOr:
+		for _, child := range condition.children {
+			if value, ok := child.parse(valueType); !ok {
+				return value, false
+			}
+		}
+		return "", true
+	}
+
+	parsed := make([]interface{}, len(condition.values))
+	for i, value := range condition.values {
+		var ok bool
+		if parsed[i], ok = valueType.parse(value); !ok {
+			return value, false
+		}
+	}
+	condition.parsed = parsed
+
+	return "", true
+}
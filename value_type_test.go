@@ -0,0 +1,76 @@
+package search
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestValueTypes(t *testing.T) {
+	tests := []struct {
+		valueType *ValueType
+		value     string
+		parsed    interface{}
+		valid     bool
+	}{
+		{valueType: TypeString, value: "joao", parsed: "joao", valid: true},
+		{valueType: TypeInt, value: "-10", parsed: int64(-10), valid: true},
+		{valueType: TypeInt, value: "1.5", valid: false},
+		{valueType: TypeInt, value: "ten", valid: false},
+		{valueType: TypeUint, value: "10", parsed: uint64(10), valid: true},
+		{valueType: TypeUint, value: "0", parsed: uint64(0), valid: true},
+		{valueType: TypeUint, value: "-1", valid: false},
+		{valueType: TypeFloat, value: "1.5", parsed: 1.5, valid: true},
+		{valueType: TypeFloat, value: "1,5", valid: false},
+		{valueType: TypeBool, value: "true", parsed: true, valid: true},
+		{valueType: TypeBool, value: "yes", valid: false},
+		{valueType: TypeDate, value: "2019-01-29", parsed: time.Date(2019, 1, 29, 0, 0, 0, 0, time.UTC), valid: true},
+		{valueType: TypeDate, value: "29-01-2019", valid: false},
+		{valueType: TypeTime, value: "2019-01-29T01:47:54Z", parsed: time.Date(2019, 1, 29, 1, 47, 54, 0, time.UTC), valid: true},
+		{valueType: TypeTime, value: "2019-01-29", valid: false},
+		{valueType: TypeUUID, value: "A0EEBC99-9C0B-4EF8-BB6D-6BB9BD380A11", parsed: "a0eebc99-9c0b-4ef8-bb6d-6bb9bd380a11", valid: true},
+		{valueType: TypeUUID, value: "a0eebc99", valid: false},
+		{valueType: TypeEnum("open", "closed"), value: "open", parsed: "open", valid: true},
+		{valueType: TypeEnum("open", "closed"), value: "pending", valid: false},
+	}
+
+	for _, test := range tests {
+		parsed, valid := test.valueType.parse(test.value)
+		if valid != test.valid {
+			t.Errorf("%s %q: valid %t, expected %t", test.valueType.name, test.value, valid, test.valid)
+		}
+
+		if test.valid && !reflect.DeepEqual(parsed, test.parsed) {
+			t.Errorf("%s %q: parsed %#v, expected %#v", test.valueType.name, test.value, parsed, test.parsed)
+		}
+	}
+}
+
+func TestConditionParse(t *testing.T) {
+	tests := []struct {
+		condition *condition
+		invalid   string
+		valid     bool
+		parsed    []interface{}
+	}{
+		{condition: &condition{column: "age", operator: operatorIn, values: []string{"1", "2"}}, valid: true, parsed: []interface{}{uint64(1), uint64(2)}},
+		{condition: &condition{column: "age", operator: operatorBetween, values: []string{"1", "-2"}}, invalid: "-2", valid: false},
+		{condition: &condition{column: "age", operator: operatorLike, values: []string{"x"}}, valid: true},
+		{condition: &condition{column: "age", operator: operatorIsNull, values: []string{"true"}}, valid: true},
+		{condition: &condition{column: "age", operator: operatorOr, children: conditions{
+			{column: "age", operator: operatorEqual, values: []string{"1"}},
+			{column: "age", operator: operatorEqual, values: []string{"x"}},
+		}}, invalid: "x", valid: false},
+	}
+
+	for _, test := range tests {
+		invalid, valid := test.condition.parse(TypeUint)
+		if valid != test.valid || invalid != test.invalid {
+			t.Errorf("%s %v: (%q, %t), expected (%q, %t)", test.condition.operator, test.condition.values, invalid, valid, test.invalid, test.valid)
+		}
+
+		if !reflect.DeepEqual(test.condition.parsed, test.parsed) {
+			t.Errorf("%s %v: parsed %v, expected %v", test.condition.operator, test.condition.values, test.condition.parsed, test.parsed)
+		}
+	}
+}